@@ -0,0 +1,519 @@
+// generated by gen_bias.go, DO NOT EDIT
+
+package cardinality
+
+// mean raw estimates at fixed cardinalities, indexed by p - 4
+var rawEstimateData = [][]float64{
+	// p = 4
+	{
+		11.2, 11.7, 12.2, 12.7, 13.3, 13.8, 14.4, 15.0,
+		15.6, 16.2, 16.8, 17.4, 18.1, 18.8, 19.5, 20.2,
+		20.9, 21.6, 22.4, 23.2, 23.9, 24.7, 25.5, 26.3,
+		27.2, 28.0, 28.9, 29.7, 30.6, 31.5, 32.4, 33.3,
+		34.2, 35.1, 36.1, 37.0, 37.9, 38.8, 39.8, 40.7,
+		41.6, 42.6, 43.5, 44.5, 45.5, 46.4, 47.4, 48.4,
+		49.4, 50.3, 51.3, 52.2, 53.2, 54.2, 55.2, 56.2,
+		57.2, 58.2, 59.2, 60.2, 61.2, 62.1, 63.1, 64.1,
+		65.2, 66.2, 67.1, 68.1, 69.1, 70.2, 71.1, 72.1,
+		73.1, 74.1, 75.1, 76.1, 77.0, 78.0, 79.0, 80.0,
+		81.0, 82.0, 83.0, 84.0, 85.0, 86.0, 87.0, 88.0,
+		89.0, 90.0, 90.9, 92.0, 93.0, 94.0, 95.0, 96.0,
+	},
+	// p = 5
+	{
+		22.8, 23.3, 23.8, 24.3, 24.8, 25.3, 25.8, 26.3,
+		26.9, 27.4, 27.9, 28.5, 29.1, 29.6, 30.2, 30.8,
+		31.4, 32.0, 32.6, 33.2, 33.9, 34.5, 35.1, 35.8,
+		36.4, 37.1, 37.8, 38.4, 39.1, 39.8, 40.5, 41.2,
+		41.9, 42.7, 43.4, 44.1, 44.9, 45.6, 46.3, 47.1,
+		47.8, 48.6, 49.4, 50.2, 50.9, 51.7, 52.5, 53.3,
+		54.2, 55.0, 55.8, 56.6, 57.4, 58.3, 59.1, 59.9,
+		60.8, 61.6, 62.5, 63.3, 64.2, 65.1, 65.9, 66.8,
+		67.7, 68.5, 69.4, 70.3, 71.2, 72.1, 73.0, 73.9,
+		74.8, 75.7, 76.7, 77.7, 78.6, 79.5, 80.5, 81.4,
+		82.3, 83.3, 84.2, 85.2, 86.1, 87.0, 88.0, 89.0,
+		89.9, 90.9, 91.8, 92.8, 93.8, 94.8, 95.7, 96.7,
+		97.7, 98.6, 99.6, 100.6, 101.5, 102.6, 103.5, 104.4,
+		105.4, 106.4, 107.4, 108.4, 109.4, 110.3, 111.3, 112.3,
+		113.3, 114.3, 115.3, 116.4, 117.3, 118.3, 119.3, 120.3,
+		121.2, 122.2, 123.2, 124.2, 125.1, 126.1, 127.1, 128.1,
+		129.0, 130.0, 131.0, 132.0, 133.1, 134.0, 135.0, 136.0,
+		137.0, 138.0, 139.1, 140.0, 141.0, 142.0, 142.9, 144.0,
+		145.0, 146.0, 147.0, 148.0, 149.0, 150.0, 150.9, 152.0,
+		153.0, 153.9, 154.9, 155.9, 156.9, 157.9, 158.9, 160.0,
+		160.9, 161.9, 162.8, 163.8, 164.8, 165.8, 166.8, 167.8,
+		168.8, 169.8, 170.8, 171.8, 172.8, 173.8, 174.7, 175.6,
+		176.7, 177.6, 178.7, 179.7, 180.7, 181.7, 182.7, 183.7,
+		184.7, 185.7, 186.7, 187.7, 188.7, 189.6, 190.7, 191.6,
+	},
+	// p = 6
+	{
+		46.8, 48.3, 49.8, 51.3, 52.9, 54.5, 56.2, 57.8,
+		59.6, 61.3, 63.1, 64.9, 66.7, 68.6, 70.5, 72.4,
+		74.4, 76.4, 78.4, 80.5, 82.6, 84.6, 86.8, 88.9,
+		91.2, 93.4, 95.6, 97.9, 100.2, 102.5, 104.9, 107.3,
+		109.7, 112.1, 114.6, 117.0, 119.6, 122.1, 124.6, 127.2,
+		129.8, 132.4, 135.0, 137.7, 140.3, 143.0, 145.7, 148.4,
+		151.1, 153.9, 156.6, 159.3, 162.0, 164.8, 167.7, 170.5,
+		173.3, 176.1, 178.9, 181.7, 184.6, 187.4, 190.4, 193.3,
+		196.2, 199.1, 202.0, 204.8, 207.8, 210.6, 213.6, 216.5,
+		219.5, 222.4, 225.4, 228.4, 231.3, 234.3, 237.1, 240.1,
+		243.1, 246.1, 249.1, 252.0, 255.0, 258.0, 261.1, 264.1,
+		267.0, 270.0, 272.9, 275.8, 278.8, 281.8, 284.8, 287.8,
+		290.9, 293.8, 296.9, 300.0, 302.9, 305.9, 308.9, 311.9,
+		315.0, 318.0, 320.9, 323.9, 327.0, 329.8, 332.9, 335.9,
+		339.0, 342.0, 345.0, 348.0, 351.1, 354.1, 357.2, 360.1,
+		363.2, 366.3, 369.3, 372.3, 375.3, 378.3, 381.4, 384.4,
+	},
+	// p = 7
+	{
+		95.0, 98.4, 102.0, 105.7, 109.4, 113.3, 117.2, 121.2,
+		125.3, 129.5, 133.8, 138.2, 142.6, 147.2, 151.8, 156.5,
+		161.4, 166.3, 171.2, 176.2, 181.4, 186.5, 191.8, 197.1,
+		202.5, 208.0, 213.5, 219.1, 224.8, 230.5, 236.4, 242.2,
+		248.2, 254.1, 260.1, 266.3, 272.4, 278.6, 284.8, 291.1,
+		297.5, 303.9, 310.2, 316.7, 323.2, 329.6, 336.2, 342.6,
+		349.2, 355.7, 362.3, 369.0, 375.7, 382.3, 389.2, 395.9,
+		402.7, 409.4, 416.2, 423.0, 429.7, 436.6, 443.5, 450.3,
+		457.2, 464.1, 471.0, 477.9, 484.6, 491.6, 498.5, 505.4,
+		512.4, 519.3, 526.2, 533.0, 540.0, 547.0, 554.0, 560.8,
+		567.7, 574.6, 581.5, 588.6, 595.6, 602.6, 609.5, 616.4,
+		623.5, 630.5, 637.5, 644.5, 651.6, 658.5, 665.4, 672.5,
+		679.4, 686.5, 693.5, 700.4, 707.5, 714.5, 721.4, 728.4,
+		735.3, 742.3, 749.4, 756.2, 763.4,
+	},
+	// p = 8
+	{
+		191.2, 198.7, 206.4, 214.3, 222.4, 230.7, 239.2, 248.0,
+		256.9, 266.0, 275.4, 284.9, 294.6, 304.5, 314.6, 324.9,
+		335.3, 346.0, 356.8, 367.8, 378.9, 390.2, 401.7, 413.4,
+		425.2, 437.1, 449.2, 461.5, 473.8, 486.3, 499.1, 511.7,
+		524.7, 537.6, 550.7, 563.9, 577.3, 590.7, 604.2, 617.8,
+		631.5, 645.4, 659.1, 673.0, 687.0, 700.9, 715.1, 729.3,
+		743.5, 757.6, 772.0, 786.3, 800.8, 815.2, 829.8, 844.4,
+		859.0, 873.7, 888.3, 902.9, 917.7, 932.5, 947.4, 962.1,
+		976.9, 991.5, 1006.3, 1021.0, 1035.9, 1050.8, 1065.7, 1080.3,
+		1095.5, 1110.5, 1125.6, 1140.5, 1155.5, 1170.5, 1185.6, 1200.3,
+		1215.2, 1230.2, 1245.0, 1259.9, 1274.9, 1289.9, 1304.8, 1319.5,
+		1334.6, 1349.7, 1364.7, 1379.7, 1394.7, 1409.5, 1424.6, 1439.6,
+		1454.5, 1469.3, 1484.4, 1499.1, 1514.0, 1529.1,
+	},
+	// p = 9
+	{
+		383.1, 398.1, 413.6, 429.4, 445.6, 462.2, 479.3, 496.8,
+		514.6, 532.9, 551.5, 570.6, 590.0, 609.9, 630.0, 650.6,
+		671.7, 693.0, 714.7, 736.7, 759.0, 781.6, 804.5, 827.7,
+		851.3, 875.3, 899.5, 924.0, 948.8, 973.9, 999.2, 1024.9,
+		1050.6, 1076.6, 1102.6, 1129.1, 1155.8, 1182.8, 1209.9, 1237.0,
+		1264.4, 1292.1, 1319.7, 1347.7, 1375.6, 1403.7, 1431.9, 1460.4,
+		1488.7, 1517.3, 1546.1, 1574.8, 1603.7, 1632.8, 1661.6, 1690.8,
+		1719.9, 1749.1, 1778.3, 1807.3, 1836.8, 1866.2, 1895.6, 1925.2,
+		1954.7, 1984.3, 2014.2, 2043.7, 2073.4, 2103.1, 2133.0, 2162.5,
+		2192.0, 2221.7, 2251.5, 2281.1, 2311.0, 2340.7, 2370.5, 2400.3,
+		2430.2, 2459.9, 2489.7, 2519.5, 2549.5, 2580.0, 2609.8, 2639.7,
+		2669.9, 2699.6, 2729.9, 2760.0, 2789.7, 2819.8, 2850.1, 2879.8,
+		2909.8, 2939.9, 2970.0, 2999.9, 3029.9, 3059.6,
+	},
+	// p = 10
+	{
+		767.6, 798.1, 829.5, 861.8, 894.9, 928.8, 963.5, 999.1,
+		1035.5, 1072.8, 1110.8, 1149.7, 1189.5, 1229.9, 1271.0, 1313.0,
+		1355.8, 1399.3, 1443.6, 1488.6, 1534.3, 1580.7, 1627.7, 1675.4,
+		1723.8, 1772.6, 1822.1, 1872.0, 1922.5, 1973.6, 2025.1, 2077.3,
+		2130.0, 2183.2, 2236.8, 2290.9, 2345.1, 2400.0, 2455.4, 2511.0,
+		2566.7, 2622.8, 2679.5, 2736.1, 2793.3, 2850.6, 2907.8, 2965.7,
+		3023.8, 3082.1, 3140.4, 3199.0, 3257.7, 3316.5, 3375.3, 3434.5,
+		3494.2, 3553.7, 3613.3, 3673.1, 3733.2, 3793.0, 3852.9, 3913.3,
+		3973.4, 4033.5, 4093.6, 4154.0, 4214.2, 4274.9, 4335.2, 4396.2,
+		4456.6, 4517.1, 4577.4, 4638.0, 4698.8, 4759.4, 4820.2, 4880.8,
+		4941.5, 5002.3, 5063.3, 5124.1, 5184.7, 5245.7, 5306.7, 5367.3,
+		5428.4, 5490.2, 5551.7, 5612.4, 5673.8, 5734.5, 5795.3, 5856.3,
+		5917.2, 5978.3, 6039.2, 6100.1,
+	},
+	// p = 11
+	{
+		1535.9, 1597.1, 1660.0, 1724.6, 1790.7, 1858.6, 1928.1, 1999.4,
+		2072.4, 2147.0, 2223.1, 2300.9, 2380.3, 2461.5, 2544.1, 2628.1,
+		2713.6, 2800.5, 2888.9, 2979.1, 3070.1, 3162.9, 3257.2, 3352.6,
+		3449.3, 3547.1, 3646.1, 3746.6, 3848.0, 3950.6, 4054.2, 4158.6,
+		4263.9, 4370.3, 4477.5, 4585.3, 4694.1, 4803.5, 4913.9, 5024.8,
+		5136.6, 5248.6, 5362.3, 5476.0, 5590.2, 5704.5, 5819.8, 5935.6,
+		6051.4, 6167.6, 6284.4, 6401.6, 6519.5, 6636.9, 6755.2, 6873.9,
+		6993.1, 7112.1, 7231.1, 7350.3, 7470.2, 7589.6, 7709.5, 7830.4,
+		7950.5, 8071.5, 8191.5, 8312.7, 8433.4, 8554.3, 8675.8, 8796.7,
+		8917.3, 9039.3, 9161.4, 9282.6, 9404.5, 9526.2, 9647.6, 9768.9,
+		9891.0, 10012.8, 10134.4, 10256.6, 10378.3, 10499.9, 10621.9, 10743.3,
+		10865.3, 10986.9, 11109.3, 11231.2, 11353.1, 11475.0, 11596.7, 11718.5,
+		11839.8, 11961.2, 12083.2, 12205.2,
+	},
+	// p = 12
+	{
+		3073.1, 3196.0, 3322.2, 3451.8, 3584.8, 3721.2, 3860.6, 4003.7,
+		4150.3, 4300.2, 4453.2, 4609.4, 4768.9, 4931.9, 5097.6, 5266.7,
+		5438.6, 5613.6, 5791.7, 5972.4, 6156.3, 6343.1, 6531.9, 6723.3,
+		6917.2, 7114.0, 7313.4, 7515.1, 7718.7, 7925.2, 8133.3, 8343.2,
+		8555.0, 8768.7, 8983.9, 9201.3, 9420.1, 9639.6, 9860.9, 10084.6,
+		10309.2, 10535.2, 10761.7, 10989.3, 11220.0, 11450.1, 11681.8, 11914.2,
+		12146.9, 12381.0, 12615.7, 12850.9, 13087.3, 13324.2, 13561.8, 13800.6,
+		14039.5, 14277.5, 14515.9, 14756.4, 14996.1, 15235.8, 15476.7, 15718.5,
+		15960.2, 16203.3, 16445.2, 16687.4, 16928.7, 17170.8, 17413.5, 17656.7,
+		17899.7, 18143.5, 18387.2, 18631.8, 18874.4, 19118.0, 19363.2, 19607.5,
+		19851.6, 20096.3, 20340.2, 20585.3, 20828.3, 21074.1, 21317.9, 21562.1,
+		21807.5, 22052.0, 22296.2, 22541.4, 22786.0, 23029.9, 23273.6, 23517.7,
+		23761.1, 24005.8, 24251.7, 24496.1,
+	},
+	// p = 13
+	{
+		6147.4, 6393.6, 6646.5, 6906.2, 7172.7, 7445.9, 7725.7, 8012.5,
+		8306.3, 8606.4, 8912.8, 9226.3, 9546.0, 9871.9, 10204.2, 10543.2,
+		10888.6, 11238.6, 11594.7, 11957.2, 12325.1, 12699.1, 13078.3, 13462.1,
+		13851.5, 14245.5, 14644.0, 15047.5, 15454.2, 15867.2, 16284.3, 16706.0,
+		17130.7, 17561.1, 17992.4, 18426.9, 18863.7, 19305.8, 19750.1, 20198.0,
+		20648.2, 21102.3, 21558.9, 22014.5, 22473.9, 22935.0, 23398.5, 23862.6,
+		24329.2, 24798.2, 25267.5, 25740.0, 26213.4, 26688.0, 27163.9, 27642.4,
+		28120.7, 28599.9, 29079.0, 29560.2, 30041.7, 30523.9, 31003.3, 31485.3,
+		31968.0, 32452.2, 32936.9, 33422.4, 33907.7, 34393.2, 34879.4, 35365.9,
+		35850.8, 36336.1, 36823.3, 37310.7, 37801.6, 38289.7, 38779.5, 39272.0,
+		39760.8, 40251.2, 40741.8, 41229.2, 41719.3, 42209.7, 42698.6, 43187.2,
+		43679.0, 44171.1, 44661.3, 45149.4, 45639.8, 46130.0, 46618.3, 47109.7,
+		47599.2, 48085.1, 48574.5, 49062.8,
+	},
+	// p = 14
+	{
+		12296.6, 12789.6, 13296.6, 13816.8, 14350.8, 14898.0, 15458.8, 16033.9,
+		16621.7, 17223.0, 17837.0, 18464.8, 19106.3, 19760.1, 20426.2, 21104.6,
+		21794.0, 22496.6, 23210.4, 23936.3, 24672.7, 25421.9, 26182.1, 26950.6,
+		27730.3, 28519.0, 29317.9, 30128.0, 30946.0, 31770.2, 32604.1, 33443.7,
+		34293.1, 35152.7, 36019.8, 36891.9, 37772.8, 38656.4, 39550.7, 40449.9,
+		41353.5, 42260.5, 43171.7, 44086.5, 45010.1, 45932.7, 46864.6, 47794.6,
+		48729.2, 49669.0, 50611.4, 51553.6, 52502.2, 53454.2, 54406.8, 55358.8,
+		56318.6, 57275.3, 58236.7, 59198.6, 60163.1, 61128.0, 62094.5, 63057.3,
+		64029.0, 64998.4, 65968.8, 66941.5, 67911.2, 68886.1, 69861.4, 70838.9,
+		71816.2, 72789.1, 73770.0, 74749.3, 75725.1, 76700.8, 77681.9, 78660.5,
+		79641.5, 80616.6, 81592.0, 82576.6, 83563.1, 84545.9, 85529.4, 86512.4,
+		87493.3, 88471.6, 89456.5, 90441.0, 91421.2, 92402.8, 93385.4, 94367.0,
+		95351.1, 96330.8, 97313.3, 98297.8,
+	},
+	// p = 15
+	{
+		24593.7, 25579.8, 26592.7, 27633.5, 28701.1, 29795.2, 30917.9, 32067.2,
+		33243.2, 34445.9, 35675.6, 36929.3, 38210.9, 39517.7, 40848.0, 42204.4,
+		43586.4, 44992.7, 46419.3, 47867.0, 49338.7, 50836.3, 52352.1, 53893.0,
+		55451.0, 57029.8, 58630.0, 60245.1, 61883.4, 63530.6, 65197.7, 66882.3,
+		68579.5, 70297.2, 72025.0, 73768.5, 75526.1, 77294.2, 79078.0, 80868.2,
+		82672.7, 84479.7, 86302.5, 88131.2, 89971.9, 91822.8, 93683.4, 95551.0,
+		97426.3, 99300.0, 101178.8, 103070.2, 104971.5, 106876.4, 108788.6, 110698.5,
+		112621.7, 114539.3, 116451.3, 118373.2, 120305.8, 122241.7, 124174.1, 126104.1,
+		128050.8, 130003.1, 131942.5, 133895.0, 135846.5, 137786.2, 139735.3, 141689.5,
+		143641.1, 145598.6, 147557.2, 149511.5, 151469.8, 153431.2, 155386.1, 157349.2,
+		159298.2, 161258.7, 163223.8, 165179.7, 167149.6, 169114.3, 171068.1, 173032.9,
+		174989.9, 176963.0, 178920.7, 180882.4, 182844.8, 184824.3, 186792.3, 188751.6,
+		190720.7, 192699.1, 194659.7, 196621.0,
+	},
+	// p = 16
+	{
+		49187.1, 51158.7, 53184.7, 55266.6, 57401.6, 59594.8, 61838.8, 64134.9,
+		66487.1, 68890.0, 71351.7, 73863.2, 76423.5, 79038.7, 81695.4, 84409.6,
+		87179.0, 89990.6, 92841.2, 95743.7, 98689.9, 101680.2, 104725.3, 107804.5,
+		110921.5, 114083.5, 117282.2, 120508.8, 123779.0, 127096.7, 130439.2, 133801.7,
+		137194.9, 140627.4, 144090.9, 147574.3, 151085.8, 154632.4, 158191.7, 161783.7,
+		165387.6, 169005.8, 172655.2, 176330.9, 180025.2, 183720.6, 187423.5, 191138.6,
+		194886.5, 198648.2, 202414.6, 206181.4, 209972.7, 213760.6, 217571.3, 221396.8,
+		225209.0, 229036.2, 232871.1, 236743.0, 240601.0, 244478.0, 248333.1, 252207.9,
+		256110.5, 259984.1, 263883.7, 267749.9, 271626.8, 275525.4, 279447.6, 283346.2,
+		287235.9, 291141.0, 295050.1, 298958.9, 302890.5, 306806.1, 310701.1, 314633.4,
+		318577.0, 322494.8, 326397.0, 330306.4, 334244.8, 338173.4, 342073.2, 345982.8,
+		349906.6, 353842.8, 357750.4, 361669.8, 365614.2, 369559.5, 373489.4, 377445.5,
+		381363.2, 385287.2, 389212.8, 393141.5,
+	},
+	// p = 17
+	{
+		98374.9, 102316.8, 106367.7, 110524.4, 114794.6, 119171.8, 123658.3, 128254.6,
+		132962.1, 137768.1, 142685.4, 147708.4, 152836.0, 158061.7, 163391.6, 168808.8,
+		174331.0, 179950.4, 185662.3, 191473.4, 197375.6, 203364.7, 209446.9, 215596.6,
+		221826.5, 228157.7, 234544.9, 241021.4, 247560.5, 254171.1, 260851.3, 267579.9,
+		274376.9, 281260.9, 288187.4, 295157.3, 302206.4, 309305.5, 316428.5, 323601.4,
+		330819.9, 338081.8, 345371.4, 352675.1, 360055.2, 367440.2, 374881.8, 382324.0,
+		389808.9, 397341.3, 404904.0, 412456.1, 420034.1, 427624.9, 435259.7, 442869.5,
+		450533.6, 458231.8, 465890.1, 473558.4, 481303.3, 489025.6, 496732.4, 504509.5,
+		512233.1, 519977.1, 527751.7, 535505.5, 543290.7, 551108.0, 558894.6, 566706.9,
+		574518.2, 582355.6, 590162.4, 597996.3, 605856.0, 613664.6, 621479.2, 629360.3,
+		637192.2, 645033.8, 652883.3, 660753.5, 668581.7, 676459.7, 684300.7, 692146.2,
+		700023.0, 707867.2, 715706.1, 723544.2, 731413.6, 739275.5, 747168.1, 755014.3,
+		762922.9, 770782.8, 778655.9, 786505.3,
+	},
+	// p = 18
+	{
+		196759.1, 204646.8, 212753.2, 221077.9, 229614.6, 238364.2, 247333.9, 256526.1,
+		265927.8, 275551.9, 285368.8, 295424.9, 305672.0, 316137.5, 326793.5, 337640.3,
+		348704.8, 359960.6, 371392.3, 383027.5, 394852.6, 406820.9, 418979.3, 431306.0,
+		443779.0, 456410.9, 469184.5, 482107.2, 495162.5, 508362.7, 521707.6, 535227.1,
+		548808.3, 562577.8, 576415.1, 590388.1, 604429.2, 618584.1, 632856.3, 647171.4,
+		661578.5, 676079.4, 690657.4, 705301.1, 720017.8, 734826.0, 749713.7, 764674.6,
+		779666.9, 794684.4, 809779.8, 824882.7, 840014.1, 855137.6, 870414.2, 885629.8,
+		900916.8, 916267.6, 931628.4, 947057.4, 962511.2, 977955.2, 993457.6, 1008893.7,
+		1024441.1, 1039980.0, 1055499.3, 1071089.6, 1086742.2, 1102322.9, 1117866.6, 1133460.1,
+		1149077.1, 1164715.5, 1180332.3, 1195964.8, 1211610.0, 1227194.4, 1242872.3, 1258568.9,
+		1274181.4, 1289927.3, 1305653.5, 1321313.1, 1336994.5, 1352707.6, 1368417.2, 1384106.0,
+		1399801.1, 1415449.0, 1431047.1, 1446754.0, 1462467.8, 1478263.7, 1493948.5, 1509640.3,
+		1525288.6, 1541077.5, 1556843.5, 1572650.5,
+	},
+}
+
+// mean bias (raw estimate - cardinality) matching rawEstimateData
+var biasData = [][]float64{
+	// p = 4
+	{
+		10.2, 9.7, 9.2, 8.7, 8.3, 7.8, 7.4, 7.0,
+		6.6, 6.2, 5.8, 5.4, 5.1, 4.8, 4.5, 4.2,
+		3.9, 3.6, 3.4, 3.2, 2.9, 2.7, 2.5, 2.3,
+		2.2, 2.0, 1.9, 1.7, 1.6, 1.5, 1.4, 1.3,
+		1.2, 1.1, 1.1, 1.0, 0.9, 0.8, 0.8, 0.7,
+		0.6, 0.6, 0.5, 0.5, 0.5, 0.4, 0.4, 0.4,
+		0.4, 0.3, 0.3, 0.2, 0.2, 0.2, 0.2, 0.2,
+		0.2, 0.2, 0.2, 0.2, 0.2, 0.1, 0.1, 0.1,
+		0.2, 0.2, 0.1, 0.1, 0.1, 0.2, 0.1, 0.1,
+		0.1, 0.1, 0.1, 0.1, 0.0, 0.0, 0.0, 0.0,
+		-0.0, -0.0, -0.0, -0.0, -0.0, -0.0, -0.0, -0.0,
+		-0.0, -0.0, -0.1, -0.0, -0.0, 0.0, -0.0, 0.0,
+	},
+	// p = 5
+	{
+		21.8, 21.3, 20.8, 20.3, 19.8, 19.3, 18.8, 18.3,
+		17.9, 17.4, 16.9, 16.5, 16.1, 15.6, 15.2, 14.8,
+		14.4, 14.0, 13.6, 13.2, 12.9, 12.5, 12.1, 11.8,
+		11.4, 11.1, 10.8, 10.4, 10.1, 9.8, 9.5, 9.2,
+		8.9, 8.7, 8.4, 8.1, 7.9, 7.6, 7.3, 7.1,
+		6.8, 6.6, 6.4, 6.2, 5.9, 5.7, 5.5, 5.3,
+		5.2, 5.0, 4.8, 4.6, 4.4, 4.3, 4.1, 3.9,
+		3.8, 3.6, 3.5, 3.3, 3.2, 3.1, 2.9, 2.8,
+		2.7, 2.5, 2.4, 2.3, 2.2, 2.1, 2.0, 1.9,
+		1.8, 1.7, 1.7, 1.7, 1.6, 1.5, 1.5, 1.4,
+		1.3, 1.3, 1.2, 1.2, 1.1, 1.0, 1.0, 1.0,
+		0.9, 0.9, 0.8, 0.8, 0.8, 0.8, 0.7, 0.7,
+		0.7, 0.6, 0.6, 0.6, 0.5, 0.6, 0.5, 0.4,
+		0.4, 0.4, 0.4, 0.4, 0.4, 0.3, 0.3, 0.3,
+		0.3, 0.3, 0.3, 0.4, 0.3, 0.3, 0.3, 0.3,
+		0.2, 0.2, 0.2, 0.2, 0.1, 0.1, 0.1, 0.1,
+		0.0, 0.0, 0.0, 0.0, 0.1, 0.0, -0.0, -0.0,
+		0.0, 0.0, 0.1, 0.0, 0.0, -0.0, -0.1, -0.0,
+		-0.0, -0.0, 0.0, -0.0, -0.0, -0.0, -0.1, -0.0,
+		-0.0, -0.1, -0.1, -0.1, -0.1, -0.1, -0.1, -0.0,
+		-0.1, -0.1, -0.2, -0.2, -0.2, -0.2, -0.2, -0.2,
+		-0.2, -0.2, -0.2, -0.2, -0.2, -0.2, -0.3, -0.4,
+		-0.3, -0.4, -0.3, -0.3, -0.3, -0.3, -0.3, -0.3,
+		-0.3, -0.3, -0.3, -0.3, -0.3, -0.4, -0.3, -0.4,
+	},
+	// p = 6
+	{
+		43.8, 42.3, 40.8, 39.3, 37.9, 36.5, 35.2, 33.8,
+		32.6, 31.3, 30.1, 28.9, 27.7, 26.6, 25.5, 24.4,
+		23.4, 22.4, 21.4, 20.5, 19.6, 18.6, 17.8, 16.9,
+		16.2, 15.4, 14.6, 13.9, 13.2, 12.5, 11.9, 11.3,
+		10.7, 10.1, 9.6, 9.0, 8.6, 8.1, 7.6, 7.2,
+		6.8, 6.4, 6.0, 5.7, 5.3, 5.0, 4.7, 4.4,
+		4.1, 3.9, 3.6, 3.3, 3.0, 2.8, 2.7, 2.5,
+		2.3, 2.1, 1.9, 1.7, 1.6, 1.4, 1.4, 1.3,
+		1.2, 1.1, 1.0, 0.8, 0.8, 0.6, 0.6, 0.5,
+		0.5, 0.4, 0.4, 0.4, 0.3, 0.3, 0.1, 0.1,
+		0.1, 0.1, 0.1, 0.0, 0.0, -0.0, 0.1, 0.1,
+		-0.0, -0.0, -0.1, -0.2, -0.2, -0.2, -0.2, -0.2,
+		-0.1, -0.2, -0.1, -0.0, -0.1, -0.1, -0.1, -0.1,
+		-0.0, -0.0, -0.1, -0.1, -0.0, -0.2, -0.1, -0.1,
+		-0.0, -0.0, -0.0, 0.0, 0.1, 0.1, 0.2, 0.1,
+		0.2, 0.3, 0.3, 0.3, 0.3, 0.3, 0.4, 0.4,
+	},
+	// p = 7
+	{
+		88.0, 84.4, 81.0, 77.7, 74.4, 71.3, 68.2, 65.2,
+		62.3, 59.5, 56.8, 54.2, 51.6, 49.2, 46.8, 44.5,
+		42.4, 40.3, 38.2, 36.2, 34.4, 32.5, 30.8, 29.1,
+		27.5, 26.0, 24.5, 23.1, 21.8, 20.5, 19.4, 18.2,
+		17.2, 16.1, 15.1, 14.3, 13.4, 12.6, 11.8, 11.1,
+		10.5, 9.9, 9.2, 8.7, 8.2, 7.6, 7.2, 6.6,
+		6.2, 5.7, 5.3, 5.0, 4.7, 4.3, 4.2, 3.9,
+		3.7, 3.4, 3.2, 3.0, 2.7, 2.6, 2.5, 2.3,
+		2.2, 2.1, 2.0, 1.9, 1.6, 1.6, 1.5, 1.4,
+		1.4, 1.3, 1.2, 1.0, 1.0, 1.0, 1.0, 0.8,
+		0.7, 0.6, 0.5, 0.6, 0.6, 0.6, 0.5, 0.4,
+		0.5, 0.5, 0.5, 0.5, 0.6, 0.5, 0.4, 0.5,
+		0.4, 0.5, 0.5, 0.4, 0.5, 0.5, 0.4, 0.4,
+		0.3, 0.3, 0.4, 0.2, 0.4,
+	},
+	// p = 8
+	{
+		176.2, 168.7, 161.4, 154.3, 147.4, 140.7, 134.2, 128.0,
+		121.9, 116.0, 110.4, 104.9, 99.6, 94.5, 89.6, 84.9,
+		80.3, 76.0, 71.8, 67.8, 63.9, 60.2, 56.7, 53.4,
+		50.2, 47.1, 44.2, 41.5, 38.8, 36.3, 34.1, 31.7,
+		29.7, 27.6, 25.7, 23.9, 22.3, 20.7, 19.2, 17.8,
+		16.5, 15.4, 14.1, 13.0, 12.0, 10.9, 10.1, 9.3,
+		8.5, 7.6, 7.0, 6.3, 5.8, 5.2, 4.8, 4.4,
+		4.0, 3.7, 3.3, 2.9, 2.7, 2.5, 2.4, 2.1,
+		1.9, 1.5, 1.3, 1.0, 0.9, 0.8, 0.7, 0.3,
+		0.5, 0.5, 0.6, 0.5, 0.5, 0.5, 0.6, 0.3,
+		0.2, 0.2, -0.0, -0.1, -0.1, -0.1, -0.2, -0.5,
+		-0.4, -0.3, -0.3, -0.3, -0.3, -0.5, -0.4, -0.4,
+		-0.5, -0.7, -0.6, -0.9, -1.0, -0.9,
+	},
+	// p = 9
+	{
+		353.1, 338.1, 323.6, 309.4, 295.6, 282.2, 269.3, 256.8,
+		244.6, 232.9, 221.5, 210.6, 200.0, 189.9, 180.0, 170.6,
+		161.7, 153.0, 144.7, 136.7, 129.0, 121.6, 114.5, 107.7,
+		101.3, 95.3, 89.5, 84.0, 78.8, 73.9, 69.2, 64.9,
+		60.6, 56.6, 52.6, 49.1, 45.8, 42.8, 39.9, 37.0,
+		34.4, 32.1, 29.7, 27.7, 25.6, 23.7, 21.9, 20.4,
+		18.7, 17.3, 16.1, 14.8, 13.7, 12.8, 11.6, 10.8,
+		9.9, 9.1, 8.3, 7.3, 6.8, 6.2, 5.6, 5.2,
+		4.7, 4.3, 4.2, 3.7, 3.4, 3.1, 3.0, 2.5,
+		2.0, 1.7, 1.5, 1.1, 1.0, 0.7, 0.5, 0.3,
+		0.2, -0.1, -0.3, -0.5, -0.5, 0.0, -0.2, -0.3,
+		-0.1, -0.4, -0.1, -0.0, -0.3, -0.2, 0.1, -0.2,
+		-0.2, -0.1, -0.0, -0.1, -0.1, -0.4,
+	},
+	// p = 10
+	{
+		706.6, 676.1, 646.5, 617.8, 589.9, 562.8, 536.5, 511.1,
+		486.5, 462.8, 439.8, 417.7, 396.5, 375.9, 356.0, 337.0,
+		318.8, 301.3, 284.6, 268.6, 253.3, 238.7, 224.7, 211.4,
+		198.8, 186.6, 175.1, 164.0, 153.5, 143.6, 134.1, 125.3,
+		117.0, 109.2, 101.8, 94.9, 88.1, 82.0, 76.4, 71.0,
+		65.7, 60.8, 56.5, 52.1, 48.3, 44.6, 40.8, 37.7,
+		34.8, 32.1, 29.4, 27.0, 24.7, 22.5, 20.3, 18.5,
+		17.2, 15.7, 14.3, 13.1, 12.2, 11.0, 9.9, 9.3,
+		8.4, 7.5, 6.6, 6.0, 5.2, 4.9, 4.2, 4.2,
+		3.6, 3.1, 2.4, 2.0, 1.8, 1.4, 1.2, 0.8,
+		0.5, 0.3, 0.3, 0.1, -0.3, -0.3, -0.3, -0.7,
+		-0.6, 0.2, 0.7, 0.4, 0.8, 0.5, 0.3, 0.3,
+		0.2, 0.3, 0.2, 0.1,
+	},
+	// p = 11
+	{
+		1413.9, 1353.1, 1294.0, 1236.6, 1180.7, 1126.6, 1074.1, 1023.4,
+		974.4, 927.0, 881.1, 836.9, 794.3, 753.5, 714.1, 676.1,
+		639.6, 604.5, 570.9, 539.1, 508.1, 478.9, 451.2, 424.6,
+		399.3, 375.1, 352.1, 330.6, 310.0, 290.6, 272.2, 254.6,
+		237.9, 222.3, 207.5, 193.3, 180.1, 167.5, 155.9, 144.8,
+		134.6, 124.6, 116.3, 108.0, 100.2, 92.5, 85.8, 79.6,
+		73.4, 67.6, 62.4, 57.6, 53.5, 48.9, 45.2, 41.9,
+		39.1, 36.1, 33.1, 30.3, 28.2, 25.6, 23.5, 22.4,
+		20.5, 19.5, 17.5, 16.7, 15.4, 14.3, 13.8, 12.7,
+		11.3, 11.3, 11.4, 10.6, 10.5, 10.2, 9.6, 8.9,
+		9.0, 8.8, 8.4, 8.6, 8.3, 7.9, 7.9, 7.3,
+		7.3, 6.9, 7.3, 7.2, 7.1, 7.0, 6.7, 6.5,
+		5.8, 5.2, 5.2, 5.2,
+	},
+	// p = 12
+	{
+		2828.1, 2706.0, 2587.2, 2471.8, 2359.8, 2251.2, 2145.6, 2043.7,
+		1945.3, 1850.2, 1758.2, 1669.4, 1583.9, 1501.9, 1422.6, 1346.7,
+		1273.6, 1203.6, 1136.7, 1072.4, 1011.3, 953.1, 896.9, 843.3,
+		792.2, 744.0, 698.4, 655.1, 613.7, 575.2, 538.3, 503.2,
+		470.0, 438.7, 408.9, 381.3, 355.1, 329.6, 305.9, 284.6,
+		264.2, 245.2, 226.7, 209.3, 195.0, 180.1, 166.8, 154.2,
+		141.9, 131.0, 120.7, 110.9, 102.3, 94.2, 86.8, 80.6,
+		74.5, 67.5, 60.9, 56.4, 51.1, 45.8, 41.7, 38.5,
+		35.2, 33.3, 30.2, 27.4, 23.7, 20.8, 18.5, 16.7,
+		14.7, 13.5, 12.2, 11.8, 9.4, 8.0, 8.2, 7.5,
+		6.6, 6.3, 5.2, 5.3, 3.3, 4.1, 2.9, 2.1,
+		2.5, 2.0, 1.2, 1.4, 1.0, -0.1, -1.4, -2.3,
+		-3.9, -4.2, -3.3, -3.9,
+	},
+	// p = 13
+	{
+		5656.4, 5411.6, 5173.5, 4942.2, 4717.7, 4499.9, 4288.7, 4084.5,
+		3887.3, 3696.4, 3511.8, 3334.3, 3163.0, 2997.9, 2839.2, 2687.2,
+		2541.6, 2400.6, 2265.7, 2137.2, 2014.1, 1897.1, 1785.3, 1678.1,
+		1576.5, 1479.5, 1387.0, 1299.5, 1215.2, 1137.2, 1063.3, 994.0,
+		927.7, 867.1, 807.4, 750.9, 696.7, 647.8, 601.1, 558.0,
+		517.2, 480.3, 445.9, 410.5, 378.9, 349.0, 321.5, 294.6,
+		270.2, 248.2, 226.5, 208.0, 190.4, 174.0, 158.9, 146.4,
+		133.7, 121.9, 110.0, 100.2, 90.7, 81.9, 70.3, 61.3,
+		53.0, 46.2, 39.9, 34.4, 28.7, 23.2, 18.4, 13.9,
+		7.8, 2.1, -1.7, -5.3, -5.4, -8.3, -9.5, -8.0,
+		-10.2, -10.8, -11.2, -14.8, -15.7, -16.3, -18.4, -20.8,
+		-20.0, -18.9, -19.7, -22.6, -23.2, -24.0, -26.7, -26.3,
+		-27.8, -32.9, -34.5, -37.2,
+	},
+	// p = 14
+	{
+		11313.6, 10823.6, 10347.6, 9884.8, 9435.8, 9000.0, 8577.8, 8169.9,
+		7774.7, 7393.0, 7024.0, 6668.8, 6327.3, 5998.1, 5681.2, 5376.6,
+		5083.0, 4802.6, 4533.4, 4276.3, 4029.7, 3795.9, 3573.1, 3358.6,
+		3155.3, 2961.0, 2776.9, 2604.0, 2439.0, 2280.2, 2131.1, 1987.7,
+		1854.1, 1730.7, 1614.8, 1503.9, 1401.8, 1302.4, 1213.7, 1129.9,
+		1050.5, 974.5, 902.7, 834.5, 775.1, 714.7, 663.6, 610.6,
+		562.2, 519.0, 478.4, 437.6, 403.2, 372.2, 341.8, 310.8,
+		287.6, 261.3, 239.7, 218.6, 200.1, 182.0, 165.5, 145.3,
+		134.0, 120.4, 107.8, 97.5, 84.2, 76.1, 68.4, 62.9,
+		57.2, 47.1, 45.0, 41.3, 34.1, 26.8, 24.9, 20.5,
+		18.5, 10.6, 3.0, 4.6, 8.1, 7.9, 8.4, 8.4,
+		6.3, 1.6, 3.5, 5.0, 2.2, 0.8, 0.4, -1.0,
+		0.1, -3.2, -3.7, -2.2,
+	},
+	// p = 15
+	{
+		22627.7, 21647.8, 20694.7, 19769.5, 18871.1, 17999.2, 17155.9, 16339.2,
+		15549.2, 14785.9, 14049.6, 13337.3, 12652.9, 11993.7, 11358.0, 10748.4,
+		10164.4, 9604.7, 9065.3, 8547.0, 8052.7, 7584.3, 7134.1, 6709.0,
+		6301.0, 5913.8, 5548.0, 5197.1, 4869.4, 4550.6, 4251.7, 3970.3,
+		3701.5, 3453.2, 3215.0, 2992.5, 2784.1, 2586.2, 2404.0, 2228.2,
+		2066.7, 1907.7, 1764.5, 1627.2, 1501.9, 1386.8, 1281.4, 1183.0,
+		1092.3, 1000.0, 912.8, 838.2, 773.5, 712.4, 658.6, 602.5,
+		559.7, 511.3, 457.3, 413.2, 379.8, 349.7, 316.1, 280.1,
+		260.8, 247.1, 220.5, 207.0, 192.5, 166.2, 149.3, 137.5,
+		123.1, 114.6, 107.2, 95.5, 87.8, 83.2, 72.1, 69.2,
+		52.2, 46.7, 45.8, 35.7, 39.6, 38.3, 26.1, 24.9,
+		15.9, 23.0, 14.7, 10.4, 6.8, 20.3, 22.3, 15.6,
+		18.7, 31.1, 25.7, 21.0,
+	},
+	// p = 16
+	{
+		45255.1, 43294.7, 41388.7, 39538.6, 37741.6, 36002.8, 34314.8, 32678.9,
+		31099.1, 29570.0, 28099.7, 26679.2, 25307.5, 23990.7, 22715.4, 21497.6,
+		20335.0, 19214.6, 18133.2, 17103.7, 16117.9, 15176.2, 14289.3, 13436.5,
+		12621.5, 11851.5, 11118.2, 10412.8, 9751.0, 9136.7, 8547.2, 7977.7,
+		7438.9, 6939.4, 6470.9, 6022.3, 5601.8, 5216.4, 4843.7, 4503.7,
+		4175.6, 3861.8, 3579.2, 3322.9, 3085.2, 2848.6, 2619.5, 2402.6,
+		2218.5, 2048.2, 1882.6, 1717.4, 1576.7, 1432.6, 1311.3, 1204.8,
+		1085.0, 980.2, 883.1, 823.0, 749.0, 694.0, 617.1, 559.9,
+		530.5, 472.1, 439.7, 373.9, 318.8, 285.4, 275.6, 242.2,
+		199.9, 173.0, 150.1, 126.9, 126.5, 110.1, 73.1, 73.4,
+		85.0, 70.8, 41.0, 18.4, 24.8, 21.4, -10.8, -33.2,
+		-41.4, -37.2, -61.6, -74.2, -61.8, -48.5, -50.6, -26.5,
+		-40.8, -48.8, -55.2, -58.5,
+	},
+	// p = 17
+	{
+		90510.9, 86588.8, 82775.7, 79068.4, 75474.6, 71987.8, 68610.3, 65342.6,
+		62186.1, 59128.1, 56181.4, 53340.4, 50604.0, 47965.7, 45431.6, 42984.8,
+		40643.0, 38398.4, 36246.3, 34193.4, 32231.6, 30356.7, 28574.9, 26860.6,
+		25226.5, 23693.7, 22216.9, 20829.4, 19504.5, 18251.1, 17067.3, 15931.9,
+		14864.9, 13884.9, 12947.4, 12053.3, 11238.4, 10473.5, 9732.5, 9041.4,
+		8395.9, 7793.8, 7219.4, 6659.1, 6175.2, 5696.2, 5273.8, 4852.0,
+		4472.9, 4141.3, 3840.0, 3528.1, 3242.1, 2968.9, 2739.7, 2485.5,
+		2285.6, 2119.8, 1914.1, 1718.4, 1599.3, 1457.6, 1300.4, 1213.5,
+		1073.1, 953.1, 863.7, 753.5, 674.7, 628.0, 550.6, 498.9,
+		446.2, 419.6, 362.4, 332.3, 328.0, 272.6, 223.2, 240.3,
+		208.2, 185.8, 171.3, 177.5, 141.7, 155.7, 132.7, 114.2,
+		127.0, 107.2, 82.1, 56.2, 61.6, 59.5, 88.1, 70.3,
+		114.9, 110.8, 119.9, 105.3,
+	},
+	// p = 18
+	{
+		181031.1, 173190.8, 165569.2, 158165.9, 150974.6, 143996.2, 137237.9, 130702.1,
+		124375.8, 118271.9, 112360.8, 106688.9, 101208.0, 95945.5, 90873.5, 85992.3,
+		81328.8, 76856.6, 72560.3, 68467.5, 64564.6, 60804.9, 57235.3, 53834.0,
+		50579.0, 47482.9, 44528.5, 41723.2, 39050.5, 36522.7, 34139.6, 31931.1,
+		29784.3, 27825.8, 25935.1, 24180.1, 22493.2, 20920.1, 19464.3, 18051.4,
+		16730.5, 15503.4, 14353.4, 13269.1, 12257.8, 11338.0, 10497.7, 9730.6,
+		8994.9, 8284.4, 7651.8, 7026.7, 6430.1, 5825.6, 5374.2, 4861.8,
+		4420.8, 4043.6, 3676.4, 3377.4, 3103.2, 2819.2, 2593.6, 2301.7,
+		2121.1, 1932.0, 1723.3, 1585.6, 1510.2, 1362.9, 1178.6, 1044.1,
+		933.1, 843.5, 732.3, 636.8, 554.0, 410.4, 360.3, 328.9,
+		213.4, 231.3, 229.5, 161.1, 114.5, 99.6, 81.2, 42.0,
+		9.1, -71.0, -200.9, -222.0, -236.2, -168.3, -211.5, -247.7,
+		-327.4, -266.5, -228.5, -149.5,
+	},
+}
@@ -0,0 +1,58 @@
+package cardinality
+
+import (
+	"sync/atomic"
+)
+
+// an HLL that can be observed from many goroutines without locking. hashing keeps no
+// state, and registers are raised with a compare-and-swap max. it is always dense, since
+// the sparse list can't be updated in place.
+type ConcurrentHLL struct {
+	registers []uint32
+	p         uint64
+	hashID    HashID
+}
+
+func MakeConcurrentHLL(p uint64) (*ConcurrentHLL, error) {
+	return MakeConcurrentHLLWithHash(p, HASH_FNV64)
+}
+
+func MakeConcurrentHLLWithHash(p uint64, hashID HashID) (*ConcurrentHLL, error) {
+	if p < 4 || p > 32 {
+		return nil, InvalidP32Error
+	}
+	if _, ok := hashFns[hashID]; !ok {
+		return nil, InvalidHashError
+	}
+	m := uint64(1) << p
+	return &ConcurrentHLL{make([]uint32, m, m), p, hashID}, nil
+}
+
+func (c *ConcurrentHLL) Observe(d []byte) {
+	c.ObserveHash(hashData(c.hashID, d))
+}
+
+func (c *ConcurrentHLL) ObserveHash(hash uint64) {
+	register := &c.registers[hash>>(64-c.p)]
+	rank := uint32(rho(hash, c.p))
+	for {
+		cur := atomic.LoadUint32(register)
+		if cur >= rank || atomic.CompareAndSwapUint32(register, cur, rank) {
+			return
+		}
+	}
+}
+
+// copies the registers into an HLL. registers only grow, so the copy is a valid sketch of
+// everything observed before the call started, plus possibly some observed during it
+func (c *ConcurrentHLL) Snapshot() *HLL {
+	hll := makeDenseHLL(c.p, c.hashID)
+	for j := range c.registers {
+		hll.raise(uint64(j), int32(atomic.LoadUint32(&c.registers[j])))
+	}
+	return hll
+}
+
+func (c *ConcurrentHLL) Estimate() int64 {
+	return c.Snapshot().Estimate()
+}
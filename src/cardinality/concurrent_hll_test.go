@@ -0,0 +1,98 @@
+package cardinality_test
+
+import (
+	"cardinality"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentHLLMatchesHLL(t *testing.T) {
+	p := uint64(12)
+	c, _ := cardinality.MakeConcurrentHLL(p)
+	hll, _ := cardinality.MakeHLL(p)
+	workers, perWorker := 32, 5000
+	observeRange(hll, 0, workers*perWorker)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// overlapping ranges, so goroutines race on the same registers
+			for i := w * perWorker; i < (w+2)*perWorker && i < workers*perWorker; i++ {
+				c.Observe([]byte(fmt.Sprintf("item-%d", i)))
+			}
+		}(w)
+	}
+	wg.Wait()
+	// any order of register max operations ends in the same registers
+	if c.Estimate() != hll.Estimate() {
+		t.Errorf("concurrent estimate %d differs from sequential %d", c.Estimate(), hll.Estimate())
+	}
+}
+
+func TestConcurrentHLLInvalidP(t *testing.T) {
+	if _, err := cardinality.MakeConcurrentHLL(33); err != cardinality.InvalidP32Error {
+		t.Errorf("expected InvalidP32Error, but was %v", err)
+	}
+}
+
+func TestConcurrentHLLSnapshotWhileObserving(t *testing.T) {
+	c, _ := cardinality.MakeConcurrentHLL(10)
+	done := make(chan bool)
+	go func() {
+		observeConcurrent(c, 0, 100000)
+		close(done)
+	}()
+	prev := int64(0)
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		est := c.Estimate()
+		if est < prev {
+			t.Errorf("estimate went down from %d to %d", prev, est)
+		}
+		prev = est
+	}
+}
+
+func observeConcurrent(c *cardinality.ConcurrentHLL, from int, to int) {
+	for i := from; i < to; i++ {
+		c.Observe([]byte(fmt.Sprintf("item-%d", i)))
+	}
+}
+
+func benchItems(n int) [][]byte {
+	items := make([][]byte, n)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("item-%d", i))
+	}
+	return items
+}
+
+func BenchmarkConcurrentHLLObserve(b *testing.B) {
+	c, _ := cardinality.MakeConcurrentHLL(14)
+	items := benchItems(1 << 16)
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			c.Observe(items[i&(len(items)-1)])
+		}
+	})
+}
+
+func BenchmarkMutexHLLObserve(b *testing.B) {
+	hll, _ := cardinality.MakeHLL(14)
+	var mu sync.Mutex
+	items := benchItems(1 << 16)
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			mu.Lock()
+			hll.Observe(items[i&(len(items)-1)])
+			mu.Unlock()
+		}
+	})
+}
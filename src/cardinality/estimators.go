@@ -0,0 +1,117 @@
+package cardinality
+
+import (
+	"math"
+)
+
+// how a dense HLL turns its registers into an estimate. all of them use linear counting at
+// SPARSE_P while the sketch is sparse, since sparse entries don't keep a rank at that precision
+type Estimator int
+
+const (
+	// raw estimate with empirical bias correction and a linear counting threshold (Heule et al.)
+	HLL_PLUS_PLUS Estimator = iota
+	// Ertl's improved raw estimator, with no empirical thresholds
+	IMPROVED_RAW
+	// Ertl's maximum likelihood estimator
+	MAX_LIKELIHOOD
+)
+
+// the limit of alpha_m as m grows, 1 / (2 ln 2)
+var ALPHA_INF = 0.5 / math.Ln2
+
+// the rest of these follow "New cardinality estimation algorithms for HyperLogLog
+// sketches" (Ertl). histogram[k] is the number of registers with rank k, for k = 0..q+1
+// where q = 64 - p
+
+func ertlSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y := 1.0
+	z := x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if z == prev {
+			return z
+		}
+	}
+}
+
+func ertlTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y := 1.0
+	z := 1 - x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// corrects the raw estimate for both empty (sigma) and saturated (tau) registers, so it
+// holds from 0 up to the limits of a 64 bit hash without switching between estimators
+func improvedRawEstimate(histogram []uint64, m uint64) float64 {
+	q := len(histogram) - 2
+	f_m := float64(m)
+	z := f_m * ertlTau(1-float64(histogram[q+1])/f_m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + float64(histogram[k]))
+	}
+	z += f_m * ertlSigma(float64(histogram[0])/f_m)
+	return ALPHA_INF * f_m * f_m / z
+}
+
+// derivative of the log likelihood of the histogram at register rate lambda = n / m, under
+// the poisson model where register k is untouched with probability e^-lambda, has rank
+// k <= q with probability e^(-lambda 2^-k) (1 - e^(-lambda 2^-k)) and rank q+1 with
+// probability 1 - e^(-lambda 2^-q)
+func logLikelihoodSlope(histogram []uint64, lambda float64) float64 {
+	q := len(histogram) - 2
+	slope := -float64(histogram[0])
+	for k := 1; k <= q+1; k++ {
+		if histogram[k] == 0 {
+			continue
+		}
+		a := negPow2[k]
+		if k == q+1 {
+			a = negPow2[q]
+		} else {
+			slope -= float64(histogram[k]) * a
+		}
+		slope += float64(histogram[k]) * a / math.Expm1(lambda*a)
+	}
+	return slope
+}
+
+// the cardinality that maximizes the likelihood of the histogram. the slope decreases in
+// lambda, so its root is found by bisecting the exponent of lambda. 50 halvings of the
+// range leave the estimate within a relative 1e-13
+func maxLikelihoodEstimate(histogram []uint64, m uint64) float64 {
+	q := len(histogram) - 2
+	if histogram[0] == m {
+		return 0
+	}
+	if histogram[q+1] == m {
+		return math.Inf(1)
+	}
+	lo, hi := -70.0, 70.0
+	for i := 0; i < 50; i++ {
+		mid := (lo + hi) / 2
+		if logLikelihoodSlope(histogram, math.Exp2(mid)) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return float64(m) * math.Exp2((lo+hi)/2)
+}
@@ -0,0 +1,85 @@
+package cardinality_test
+
+import (
+	"cardinality"
+	"math"
+	"testing"
+)
+
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+var estimators = []cardinality.Estimator{cardinality.HLL_PLUS_PLUS, cardinality.IMPROVED_RAW, cardinality.MAX_LIKELIHOOD}
+
+// sweeps the cardinality from 1 to 10^8 in steps of 5%. a switch between estimators shows
+// up as an error spike or as the estimate going down while registers only go up
+func TestEstimatorSweep(t *testing.T) {
+	if testing.Short() {
+		t.Skip("sweeps 10^8 items")
+	}
+	p := uint64(12)
+	maxErr := 4 * 1.04 / math.Sqrt(float64(uint64(1)<<p))
+	hll, _ := cardinality.MakeHLL(p)
+	state := uint64(0)
+	prev := make([]int64, len(estimators))
+	n := 0
+	for next := 1.0; next <= 1e8; next *= 1.05 {
+		for ; float64(n) < next; n++ {
+			hll.ObserveHash(splitmix64(&state))
+		}
+		for i, e := range estimators {
+			hll.SetEstimator(e)
+			est := hll.Estimate()
+			if err := relErr(est, n); err > maxErr {
+				t.Errorf("estimator %d at n=%d: relative error %f > %f", e, n, err, maxErr)
+			}
+			if est < prev[i] {
+				t.Errorf("estimator %d at n=%d: estimate went down from %d to %d", e, n, prev[i], est)
+			}
+			prev[i] = est
+		}
+	}
+}
+
+func TestMaxLikelihoodAgreesWithImprovedRaw(t *testing.T) {
+	hll, _ := cardinality.MakeHLL(14)
+	state := uint64(7)
+	for _, n := range []int{10000, 100000, 1000000} {
+		for i := 0; i < n; i++ {
+			hll.ObserveHash(splitmix64(&state))
+		}
+		hll.SetEstimator(cardinality.IMPROVED_RAW)
+		raw := hll.Estimate()
+		hll.SetEstimator(cardinality.MAX_LIKELIHOOD)
+		if ml := hll.Estimate(); relErr(ml, int(raw)) > 0.002 {
+			t.Errorf("max likelihood estimate %d is far from improved raw %d", ml, raw)
+		}
+	}
+}
+
+func TestEstimatorSurvivesMerge(t *testing.T) {
+	a, _ := cardinality.MakeHLL(12)
+	b, _ := cardinality.MakeHLL(10)
+	a.SetEstimator(cardinality.MAX_LIKELIHOOD)
+	observeRange(a, 0, 50000)
+	observeRange(b, 0, 50000)
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	// folding to p=10 gives the registers of observing the items at p=10
+	folded, _ := cardinality.MakeHLL(10)
+	folded.SetEstimator(cardinality.MAX_LIKELIHOOD)
+	observeRange(folded, 0, 50000)
+	if a.Estimate() != folded.Estimate() {
+		t.Errorf("merged sketch estimated %d, the max likelihood estimate at p=10 is %d", a.Estimate(), folded.Estimate())
+	}
+	folded.SetEstimator(cardinality.HLL_PLUS_PLUS)
+	if a.Estimate() == folded.Estimate() {
+		t.Errorf("the estimators should differ here, both gave %d", a.Estimate())
+	}
+}
@@ -1,22 +1,85 @@
 package cardinality
 
+//go:generate sh -c "go run gen_bias.go > bias_data.go"
+
 import (
 	"errors"
-	"hash"
-	"hash/fnv"
 	"math"
-	)
+	"sort"
+)
 
 var InvalidPError = errors.New("p has to be between 4 and 64")
+var InvalidP32Error = errors.New("p has to be between 4 and 32")
+var InvalidHashError = errors.New("unknown hash id")
+var HashMismatchError = errors.New("sketches use different hash functions")
 var POW_2_64 float64 = 18446744073709551616.0
 var POW_NEG_2_64 float64 = -18446744073709551616.0
 
+// identifies the hash function of a sketch. sketches can only be combined when they match
+type HashID uint8
+
+const (
+	HASH_FNV64  HashID = 1 // fnv-1, 64 bit
+	HASH_FNV64A HashID = 2 // fnv-1a, 64 bit
+)
+
+var hashFns = map[HashID]func(data []byte) uint64{
+	HASH_FNV64:  fnv64,
+	HASH_FNV64A: fnv64a,
+}
+
+const FNV64_OFFSET uint64 = 14695981039346656037
+const FNV64_PRIME uint64 = 1099511628211
+
+// fnv computed inline rather than through hash.Hash64, so hashing keeps no state
+// and is safe from any number of goroutines
+func fnv64(data []byte) uint64 {
+	h := FNV64_OFFSET
+	for _, b := range data {
+		h *= FNV64_PRIME
+		h ^= uint64(b)
+	}
+	return h
+}
+
+func fnv64a(data []byte) uint64 {
+	h := FNV64_OFFSET
+	for _, b := range data {
+		h ^= uint64(b)
+		h *= FNV64_PRIME
+	}
+	return h
+}
+
+// the hash a sketch observes for data
+func hashData(hashID HashID, data []byte) uint64 {
+	return mix64(hashFns[hashID](data))
+}
+
+// precision (p') of the sparse representation. sketches with p <= SPARSE_P start out sparse
+const SPARSE_P uint64 = 25
+
+// number of nearest raw estimates averaged when looking up the bias
+const BIAS_NEIGHBORS = 6
+
+// cardinalities below which linear counting is preferred over the bias corrected estimate,
+// for p = 4..18. taken from Heule, Nunkesser, Hall
+var thresholdData = []float64{10, 20, 40, 80, 220, 400, 900, 1800, 3100, 6500, 11500, 20000, 50000, 120000, 350000}
+
+// HyperLogLog++, from "HyperLogLog in Practice" (Heule, Nunkesser, Hall)
+// small sketches keep a sparse list of encoded hashes at precision SPARSE_P and
+// convert to the dense registers once the list would use more memory than they do
 type HLL struct {
-	registers []int32
-	m uint64
-	p uint64
-	alphaM float64
-	h hash.Hash64
+	registers []int32  // dense registers, nil while the sketch is sparse
+	harmonic  float64  // sum of 2^-register, kept up to date as registers are raised
+	histogram []uint64 // number of registers at every rank from 0 to 64-p+1
+	sparse    []uint32 // encoded hashes sorted and unique by sparse index
+	tmp       []uint32 // encoded hashes not yet merged into sparse
+	m         uint64
+	p         uint64
+	alphaM    float64
+	hashID    HashID
+	estimator Estimator
 }
 
 func Nlz64(x uint64) int32 {
@@ -37,62 +100,393 @@ func Nlz64(x uint64) int32 {
 }
 
 func ShiftedNlz64(x uint64, shift uint64) int32 {
-	return Nlz64((x << shift) | 1 << (shift - 1))
+	return Nlz64((x << shift) | 1<<(shift-1))
+}
+
+// rank of the first 1 bit after the top p bits, so an empty register (0) can be told apart
+func rho(x uint64, p uint64) int32 {
+	return ShiftedNlz64(x, p) + 1
+}
+
+// murmur3's 64 bit finalizer. fnv on its own barely changes the top bits (which pick
+// the register) when only the last bytes of the input differ
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
 }
 
 func MakeHLL(p uint64) (*HLL, error) {
+	return MakeHLLWithHash(p, HASH_FNV64)
+}
+
+func MakeHLLWithHash(p uint64, hashID HashID) (*HLL, error) {
 	if p < 4 || p > 64 {
 		return nil, InvalidPError
 	}
+	if _, ok := hashFns[hashID]; !ok {
+		return nil, InvalidHashError
+	}
 	m := uint64(1 << p)
 	f_m := float64(m)
-	registers := make([]int32, m, m)
 	alphaM := 0.0
 	switch p {
-	case 4:	alphaM = 0.673 * f_m * f_m
-	case 5:	alphaM = 0.697 * f_m * f_m
-	case 6:	alphaM = 0.709 * f_m * f_m
-	default: alphaM = (0.7213 / (1 + 1.079 / f_m)) * f_m * f_m
+	case 4:
+		alphaM = 0.673 * f_m * f_m
+	case 5:
+		alphaM = 0.697 * f_m * f_m
+	case 6:
+		alphaM = 0.709 * f_m * f_m
+	default:
+		alphaM = (0.7213 / (1 + 1.079/f_m)) * f_m * f_m
+	}
+	hll := &HLL{m: m, p: p, alphaM: alphaM, hashID: hashID}
+	if p > SPARSE_P {
+		hll.initDense()
+	}
+	return hll, nil
+}
+
+// 2^-k for every possible rank
+var negPow2 [66]float64
+
+func init() {
+	for k := range negPow2 {
+		negPow2[k] = math.Pow(2, -float64(k))
+	}
+}
+
+func (hll *HLL) initDense() {
+	hll.registers = make([]int32, hll.m, hll.m)
+	hll.harmonic = float64(hll.m)
+	hll.histogram = make([]uint64, 64-hll.p+2)
+	hll.histogram[0] = hll.m
+}
+
+// zeroes the dense registers in place
+func (hll *HLL) clearDense() {
+	for j := range hll.registers {
+		hll.registers[j] = 0
+	}
+	for k := range hll.histogram {
+		hll.histogram[k] = 0
+	}
+	hll.harmonic = float64(hll.m)
+	hll.histogram[0] = hll.m
+}
+
+// raises register j to rank if it is lower. every dense register write goes through here,
+// which keeps the harmonic sum and histogram current and Estimate constant time. a register
+// is raised at most 64-p+1 times, which bounds the rounding the harmonic sum accumulates
+func (hll *HLL) raise(j uint64, rank int32) {
+	if old := hll.registers[j]; old < rank {
+		hll.registers[j] = rank
+		hll.harmonic += negPow2[rank] - negPow2[old]
+		hll.histogram[old]--
+		hll.histogram[rank]++
 	}
-	return &HLL{registers, m, p, alphaM, fnv.New64()}, nil
+}
+
+// a sketch that starts out with dense registers, for callers that raise them directly
+func makeDenseHLL(p uint64, hashID HashID) *HLL {
+	hll, _ := MakeHLLWithHash(p, hashID)
+	if hll.isSparse() {
+		hll.toDense()
+	}
+	return hll
 }
 
 func (hll *HLL) Observe(d []byte) {
-	hll.h.Reset()
-	hll.h.Write(d)
-	hash := hll.h.Sum64()
+	hll.ObserveHash(hashData(hll.hashID, d))
+}
+
+// observes an already hashed item. all 64 bits of hash should be uniformly distributed
+func (hll *HLL) ObserveHash(hash uint64) {
+	if hll.isSparse() {
+		hll.tmp = append(hll.tmp, encodeHash(hash, hll.p))
+		if len(hll.tmp) >= hll.tmpLimit() {
+			hll.mergeTmp()
+		}
+		return
+	}
 	// r is the register that we will update. R is between 0 and 2^p
 	r := hash >> (64 - hll.p)
+	hll.raise(r, rho(hash, hll.p))
+}
+
+func (hll *HLL) isSparse() bool {
+	return hll.registers == nil
+}
+
+// sparse entries and dense registers both take 4 bytes, and tmp holds up to a quarter of the
+// limit on top of the sparse list, so at 4/5 of m the two lists together still fit in the
+// registers
+func (hll *HLL) sparseLimit() int {
+	return int(hll.m * 4 / 5)
+}
 
-	nlz := ShiftedNlz64(hash, hll.p)
-	if hll.registers[r] < nlz {
-		hll.registers[r] = nlz
+func (hll *HLL) tmpLimit() int {
+	if limit := hll.sparseLimit() / 4; limit > 1 {
+		return limit
 	}
+	return 1
 }
 
-func (hll *HLL) Estimate() int64 {
-	harmonic_m := 0.0
-	num_0 := 0
-	for _, r := range(hll.registers) {
-		harmonic_m += math.Pow(2, float64(-1 * r))
+// encodes a hash at precision SPARSE_P. when the bits between p and SPARSE_P are all zero,
+// the rank of the remaining bits is needed to recover the dense register and is stored in
+// bits 1-6 (flag bit 0 set). otherwise those bits already determine the rank.
+func encodeHash(x uint64, p uint64) uint32 {
+	idx := uint32(x >> (64 - SPARSE_P))
+	if idx&(1<<(SPARSE_P-p)-1) == 0 {
+		return idx<<7 | uint32(rho(x, SPARSE_P))<<1 | 1
+	}
+	return idx << 1
+}
+
+func sparseIndex(k uint32) uint32 {
+	if k&1 == 1 {
+		return k >> 7
+	}
+	return k >> 1
+}
+
+// returns the dense register and rank at precision p of an encoded hash
+func decodeHash(k uint32, p uint64) (uint64, int32) {
+	idx := sparseIndex(k)
+	if k&1 == 1 {
+		return uint64(idx >> (SPARSE_P - p)), int32((k>>1)&63) + int32(SPARSE_P-p)
+	}
+	// rank comes from the bits between p and SPARSE_P, moved to the top
+	rest := uint64(idx) << (64 - (SPARSE_P - p))
+	return uint64(idx >> (SPARSE_P - p)), Nlz64(rest) + 1
+}
+
+type encodedHashes []uint32
+
+func (e encodedHashes) Len() int      { return len(e) }
+func (e encodedHashes) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e encodedHashes) Less(i, j int) bool {
+	a, b := sparseIndex(e[i]), sparseIndex(e[j])
+	return a < b || (a == b && e[i] < e[j])
+}
+
+// merges tmp into the sparse list, keeping the largest rank per sparse index, and converts to
+// dense once the list passes sparseLimit, so a sketch's form only depends on what it has seen
+func (hll *HLL) mergeTmp() {
+	if len(hll.tmp) == 0 {
+		return
+	}
+	all := append(hll.sparse, hll.tmp...)
+	sort.Sort(encodedHashes(all))
+	merged := all[:0]
+	for _, k := range all {
+		if n := len(merged); n > 0 && sparseIndex(merged[n-1]) == sparseIndex(k) {
+			merged[n-1] = k
+			continue
+		}
+		merged = append(merged, k)
+	}
+	hll.sparse = merged
+	hll.tmp = hll.tmp[:0]
+	if len(hll.sparse) > hll.sparseLimit() {
+		hll.toDense()
+	}
+}
+
+func (hll *HLL) toDense() {
+	// merging may have converted already
+	if hll.mergeTmp(); !hll.isSparse() {
+		return
+	}
+	hll.initDense()
+	for _, k := range hll.sparse {
+		hll.raise(decodeHash(k, hll.p))
+	}
+	hll.sparse = nil
+	hll.tmp = nil
+}
+
+// re-encodes a hash encoded for any precision q >= p so it is valid for p
+func reencode(k uint32, p uint64) uint32 {
+	if idx := sparseIndex(k); k&1 == 1 && idx&(1<<(SPARSE_P-p)-1) != 0 {
+		return idx << 1
+	}
+	return k
+}
+
+// adds hashes encoded for a precision >= hll.p
+func (hll *HLL) addEncoded(ks []uint32) {
+	for _, k := range ks {
+		k = reencode(k, hll.p)
+		if hll.isSparse() {
+			hll.tmp = append(hll.tmp, k)
+			continue
+		}
+		hll.raise(decodeHash(k, hll.p))
+	}
+	if hll.isSparse() {
+		hll.mergeTmp()
+	}
+}
+
+// returns a copy of the sketch at precision p <= hll.p. a register j at precision q
+// covers registers j << (q-p) ... at p, and the index bits that get dropped become
+// the leading bits of the rank.
+func (hll *HLL) fold(p uint64) *HLL {
+	folded, _ := MakeHLLWithHash(p, hll.hashID)
+	folded.estimator = hll.estimator
+	if hll.isSparse() {
+		folded.addEncoded(hll.sparse)
+		folded.addEncoded(hll.tmp)
+		return folded
+	}
+	if folded.isSparse() && hll.p >= SPARSE_P && hll.nonZeroRegisters() <= folded.sparseLimit() {
+		// registers at or above SPARSE_P keep all the bits of a sparse entry, so the result
+		// stays sparse like a sketch that observed the items at p
+		for j, r := range hll.registers {
+			if r != 0 {
+				folded.tmp = append(folded.tmp, encodeHash(registerHash(uint64(j), r, hll.p), p))
+			}
+		}
+		folded.mergeTmp()
+		return folded
+	}
+	if folded.isSparse() {
+		folded.toDense()
+	}
+	shift := hll.p - p
+	for j, r := range hll.registers {
 		if r == 0 {
-			num_0 += 1
+			continue
 		}
+		rank := int32(shift) + r
+		if low := uint64(j) & (1<<shift - 1); low != 0 {
+			rank = Nlz64(low<<(64-shift)) + 1
+		}
+		folded.raise(uint64(j)>>shift, rank)
 	}
-	est := hll.alphaM * (1.0 / harmonic_m)
+	return folded
+}
 
-	if est <= (5.0/2.0 * float64(hll.m)) {
-		if num_0 != 0 {
-			est = LinearCounting(hll.m, float64(num_0))
+func (hll *HLL) nonZeroRegisters() int {
+	return int(hll.m - hll.histogram[0])
+}
+
+// the smallest hash that sets register j to rank r at precision p
+func registerHash(j uint64, r int32, p uint64) uint64 {
+	x := j << (64 - p)
+	if r <= int32(64-p) {
+		x |= 1 << (64 - p - uint64(r))
+	}
+	return x
+}
+
+// merges other into hll by taking the max of every register. if the precisions differ,
+// the result has the smaller one
+func (hll *HLL) Merge(other *HLL) error {
+	if hll.hashID != other.hashID {
+		return HashMismatchError
+	}
+	if other.p < hll.p {
+		*hll = *hll.fold(other.p)
+	} else if other.p > hll.p {
+		other = other.fold(hll.p)
+	}
+	if other.isSparse() {
+		hll.addEncoded(other.sparse)
+		hll.addEncoded(other.tmp)
+		return nil
+	}
+	if hll.isSparse() {
+		hll.toDense()
+	}
+	for j, r := range other.registers {
+		hll.raise(uint64(j), r)
+	}
+	return nil
+}
+
+// returns a new sketch of everything observed by hll and others, at the smallest precision among them
+func (hll *HLL) Union(others ...*HLL) (*HLL, error) {
+	result := hll.fold(hll.p)
+	for _, other := range others {
+		if err := result.Merge(other); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (hll *HLL) Estimate() int64 {
+	hll.mergeTmp()
+	if hll.isSparse() {
+		// linear counting at precision SPARSE_P, rounded since a ceil would add 1 to nearly every result
+		m := float64(uint64(1) << SPARSE_P)
+		return int64(math.Floor(m*math.Log(m/(m-float64(len(hll.sparse)))) + 0.5))
+	}
+	switch hll.estimator {
+	case IMPROVED_RAW:
+		return int64(math.Floor(improvedRawEstimate(hll.histogram, hll.m) + 0.5))
+	case MAX_LIKELIHOOD:
+		return int64(math.Floor(maxLikelihoodEstimate(hll.histogram, hll.m) + 0.5))
+	}
+	num_0 := hll.histogram[0]
+	est := hll.alphaM * (1.0 / hll.harmonic)
+	if est <= 5*float64(hll.m) {
+		est -= estimateBias(est, hll.p)
+	}
+
+	// 64 bit hashes make the large range correction unnecessary
+	if num_0 != 0 {
+		if lc := LinearCounting(hll.m, float64(num_0)); lc <= threshold(hll.p, hll.m) {
+			return int64(math.Floor(lc + 0.5))
 		}
-		// est = est
-	} else if est > (1/30 * POW_2_64) { // revisit 1/30
-		est = POW_NEG_2_64 * math.Log(1 - (est / POW_2_64))
 	}
 	return int64(math.Ceil(est))
 }
 
+// picks how dense registers are turned into an estimate, HLL_PLUS_PLUS by default. it is not
+// part of the encoding, and merged sketches keep the receiver's
+func (hll *HLL) SetEstimator(e Estimator) {
+	hll.estimator = e
+}
+
+func threshold(p uint64, m uint64) float64 {
+	if i := int(p) - 4; i < len(thresholdData) {
+		return thresholdData[i]
+	}
+	return 5.0 / 2.0 * float64(m)
+}
+
+// averages the bias of the BIAS_NEIGHBORS raw estimates closest to est
+func estimateBias(est float64, p uint64) float64 {
+	i := int(p) - 4
+	if i >= len(rawEstimateData) {
+		return 0
+	}
+	raw, bias := rawEstimateData[i], biasData[i]
+	lo := sort.SearchFloat64s(raw, est)
+	hi := lo
+	for hi-lo < BIAS_NEIGHBORS {
+		if lo > 0 && (hi == len(raw) || est-raw[lo-1] < raw[hi]-est) {
+			lo--
+		} else if hi < len(raw) {
+			hi++
+		} else {
+			break
+		}
+	}
+	sum := 0.0
+	for _, b := range bias[lo:hi] {
+		sum += b
+	}
+	return sum / float64(hi-lo)
+}
+
+// the cardinality of m registers of which v are empty, unrounded
 func LinearCounting(m uint64, v float64) float64 {
-	return math.Ceil(float64(m) * math.Log(float64(m) / v))
+	return float64(m) * math.Log(float64(m)/v)
 }
-	
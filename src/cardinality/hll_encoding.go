@@ -0,0 +1,178 @@
+package cardinality
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// layout of an encoded HLL, version 1:
+//
+//	byte 0   version
+//	byte 1   hash id
+//	byte 2   p
+//	byte 3   HLL_DENSE or HLL_SPARSE
+//	dense:   m registers packed at 6 bits each, least significant bits first
+//	sparse:  uvarint entry count, then per entry the uvarint delta of its sparse index
+//	         from the previous one and a byte holding the rank and flag bits
+const HLL_ENCODING_VERSION byte = 1
+
+const (
+	HLL_DENSE  byte = 0
+	HLL_SPARSE byte = 1
+)
+
+const hllHeaderLen = 4
+const registerBits = 6
+
+var EncodingVersionError = errors.New("unsupported encoding version")
+var CorruptEncodingError = errors.New("corrupt encoding")
+var PrecisionMismatchError = errors.New("sketches use different precisions")
+
+func (hll *HLL) MarshalBinary() ([]byte, error) {
+	header := []byte{HLL_ENCODING_VERSION, byte(hll.hashID), byte(hll.p), HLL_DENSE}
+	hll.mergeTmp()
+	if !hll.isSparse() {
+		return append(header, packRegisters(hll.registers)...), nil
+	}
+	header[3] = HLL_SPARSE
+	buf := make([]byte, binary.MaxVarintLen64)
+	data := append(header, buf[:binary.PutUvarint(buf, uint64(len(hll.sparse)))]...)
+	prev := uint32(0)
+	for _, k := range hll.sparse {
+		idx := sparseIndex(k)
+		data = append(data, buf[:binary.PutUvarint(buf, uint64(idx-prev))]...)
+		low := byte(0)
+		if k&1 == 1 {
+			low = byte(k & 0x7f)
+		}
+		data = append(data, low)
+		prev = idx
+	}
+	return data, nil
+}
+
+// decodes data into hll. a zero HLL takes on the encoded precision and hash, an
+// initialized one rejects data encoded with different ones
+func (hll *HLL) UnmarshalBinary(data []byte) error {
+	if len(data) < hllHeaderLen {
+		return CorruptEncodingError
+	}
+	if data[0] != HLL_ENCODING_VERSION {
+		return EncodingVersionError
+	}
+	hashID, p := HashID(data[1]), uint64(data[2])
+	if hll.m != 0 && hll.hashID != hashID {
+		return HashMismatchError
+	}
+	if hll.m != 0 && hll.p != p {
+		return PrecisionMismatchError
+	}
+	mode, data := data[3], data[hllHeaderLen:]
+	// check p against the payload before it decides how much gets allocated
+	switch mode {
+	case HLL_DENSE:
+		if p > 40 || uint64(len(data)) != (uint64(1)<<p*registerBits+7)/8 {
+			return CorruptEncodingError
+		}
+	case HLL_SPARSE:
+		if p > SPARSE_P {
+			return CorruptEncodingError
+		}
+	default:
+		return CorruptEncodingError
+	}
+	decoded, err := MakeHLLWithHash(p, hashID)
+	if err != nil {
+		return err
+	}
+	if mode == HLL_DENSE {
+		if decoded.isSparse() {
+			decoded.toDense()
+		}
+		err = decoded.unpackRegisters(data)
+	} else {
+		err = decoded.unpackSparse(data)
+	}
+	if err != nil {
+		return err
+	}
+	decoded.estimator = hll.estimator
+	*hll = *decoded
+	return nil
+}
+
+func packRegisters(registers []int32) []byte {
+	packed := make([]byte, (len(registers)*registerBits+7)/8)
+	for j, r := range registers {
+		bit := j * registerBits
+		v := uint16(r) << uint(bit%8)
+		packed[bit/8] |= byte(v)
+		if v >>= 8; v != 0 {
+			packed[bit/8+1] |= byte(v)
+		}
+	}
+	return packed
+}
+
+func (hll *HLL) unpackRegisters(packed []byte) error {
+	if uint64(len(packed)) != (hll.m*registerBits+7)/8 {
+		return CorruptEncodingError
+	}
+	maxRank := int32(64 - hll.p + 1)
+	for j := range hll.registers {
+		bit := j * registerBits
+		v := uint16(packed[bit/8])
+		if bit/8+1 < len(packed) {
+			v |= uint16(packed[bit/8+1]) << 8
+		}
+		r := int32(v>>uint(bit%8)) & (1<<registerBits - 1)
+		if r > maxRank {
+			return CorruptEncodingError
+		}
+		hll.raise(uint64(j), r)
+	}
+	return nil
+}
+
+func (hll *HLL) unpackSparse(data []byte) error {
+	n, read := binary.Uvarint(data)
+	if read <= 0 || n > uint64(len(data)) {
+		return CorruptEncodingError
+	}
+	data = data[read:]
+	sparse := make([]uint32, 0, n)
+	idx := uint64(0)
+	for i := uint64(0); i < n; i++ {
+		delta, read := binary.Uvarint(data)
+		if read <= 0 || read >= len(data) || (i > 0 && delta == 0) {
+			return CorruptEncodingError
+		}
+		if idx += delta; idx >= 1<<SPARSE_P {
+			return CorruptEncodingError
+		}
+		// the flag has to agree with the index bits, or decoding would give a different rank
+		k, low := uint32(idx)<<1, uint32(data[read])
+		flagged := idx&(1<<(SPARSE_P-hll.p)-1) == 0
+		switch {
+		case flagged != (low&1 == 1):
+			return CorruptEncodingError
+		case flagged:
+			if rank := low >> 1; rank == 0 || rank > uint32(64-SPARSE_P+1) {
+				return CorruptEncodingError
+			}
+			k = uint32(idx)<<7 | low
+		case low != 0:
+			return CorruptEncodingError
+		}
+		sparse = append(sparse, k)
+		data = data[read+1:]
+	}
+	if len(data) != 0 {
+		return CorruptEncodingError
+	}
+	hll.sparse = sparse
+	if len(hll.sparse) > hll.sparseLimit() {
+		hll.toDense()
+	}
+	return nil
+}
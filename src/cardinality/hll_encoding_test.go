@@ -0,0 +1,71 @@
+package cardinality_test
+
+import (
+	"cardinality"
+	"testing"
+)
+
+func roundTrip(t *testing.T, hll *cardinality.HLL) *cardinality.HLL {
+	data, err := hll.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded cardinality.HLL
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	return &decoded
+}
+
+func TestHLLRoundTrip(t *testing.T) {
+	for _, p := range []uint64{4, 11, 14} {
+		for _, n := range []int{0, 30, 2000, 200000} {
+			hll, _ := cardinality.MakeHLL(p)
+			observeRange(hll, 0, n)
+			decoded := roundTrip(t, hll)
+			if decoded.Estimate() != hll.Estimate() {
+				t.Errorf("p=%d n=%d: estimate was %d before encoding and %d after", p, n, hll.Estimate(), decoded.Estimate())
+			}
+
+			// the decoded sketch keeps counting where the original left off
+			observeRange(hll, n, n+1000)
+			observeRange(decoded, n, n+1000)
+			if decoded.Estimate() != hll.Estimate() {
+				t.Errorf("p=%d n=%d: estimates diverged after decoding, %d vs %d", p, n, hll.Estimate(), decoded.Estimate())
+			}
+		}
+	}
+}
+
+func TestHLLPackedSize(t *testing.T) {
+	hll, _ := cardinality.MakeHLL(12)
+	observeRange(hll, 0, 100000)
+	data, _ := hll.MarshalBinary()
+	if expected := 4 + 4096*6/8; len(data) != expected {
+		t.Errorf("dense encoding should be %d bytes, but was %d", expected, len(data))
+	}
+}
+
+func TestHLLUnmarshalMismatch(t *testing.T) {
+	hll, _ := cardinality.MakeHLL(12)
+	observeRange(hll, 0, 100)
+	data, _ := hll.MarshalBinary()
+
+	otherP, _ := cardinality.MakeHLL(10)
+	if err := otherP.UnmarshalBinary(data); err != cardinality.PrecisionMismatchError {
+		t.Errorf("expected PrecisionMismatchError, but was %v", err)
+	}
+	otherHash, _ := cardinality.MakeHLLWithHash(12, cardinality.HASH_FNV64A)
+	if err := otherHash.UnmarshalBinary(data); err != cardinality.HashMismatchError {
+		t.Errorf("expected HashMismatchError, but was %v", err)
+	}
+
+	data[0] = 99
+	if err := otherP.UnmarshalBinary(data); err != cardinality.EncodingVersionError {
+		t.Errorf("expected EncodingVersionError, but was %v", err)
+	}
+	var empty cardinality.HLL
+	if err := empty.UnmarshalBinary(data[:2]); err != cardinality.CorruptEncodingError {
+		t.Errorf("expected CorruptEncodingError, but was %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package cardinality
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func observeItems(hll *HLL, from int, to int) *HLL {
+	for i := from; i < to; i++ {
+		hll.Observe([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	return hll
+}
+
+// the harmonic sum and histogram are kept up to date by raise, so they have to match a
+// recompute from the registers after every way a dense sketch gets its registers
+func TestHLLMaintainedSums(t *testing.T) {
+	sparse := func(p uint64) *HLL {
+		hll, _ := MakeHLL(p)
+		return hll
+	}
+	merged := func(a *HLL, b *HLL) *HLL {
+		if err := a.Merge(b); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	decoded := func(hll *HLL) *HLL {
+		data, err := hll.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result HLL
+		if err := result.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		return &result
+	}
+	cases := []struct {
+		name  string
+		build func() *HLL
+	}{
+		{"observe", func() *HLL { return observeItems(makeDenseHLL(12, HASH_FNV64), 0, 100000) }},
+		{"sparse to dense", func() *HLL { return observeItems(sparse(12), 0, 5000) }},
+		{"merge", func() *HLL {
+			return merged(observeItems(makeDenseHLL(12, HASH_FNV64), 0, 50000), observeItems(makeDenseHLL(12, HASH_FNV64), 25000, 75000))
+		}},
+		{"merge sparse into dense", func() *HLL {
+			return merged(observeItems(makeDenseHLL(12, HASH_FNV64), 0, 50000), observeItems(sparse(12), 50000, 50100))
+		}},
+		{"fold", func() *HLL { return merged(observeItems(makeDenseHLL(14, HASH_FNV64), 0, 100000), sparse(10)) }},
+		{"fold sparse to dense", func() *HLL { return merged(observeItems(sparse(14), 0, 2000), sparse(8)) }},
+		{"unmarshal", func() *HLL { return decoded(observeItems(makeDenseHLL(12, HASH_FNV64), 0, 100000)) }},
+	}
+	for _, c := range cases {
+		hll := c.build()
+		if hll.isSparse() {
+			t.Fatalf("%s: sketch should be dense", c.name)
+		}
+		harmonic, histogram := 0.0, make([]uint64, len(hll.histogram))
+		for _, r := range hll.registers {
+			harmonic += math.Pow(2, -float64(r))
+			histogram[r]++
+		}
+		if math.Abs(hll.harmonic-harmonic) > 1e-9*harmonic {
+			t.Errorf("%s: harmonic sum %f, recomputed %f", c.name, hll.harmonic, harmonic)
+		}
+		for k := range histogram {
+			if hll.histogram[k] != histogram[k] {
+				t.Errorf("%s: %d registers at rank %d, recomputed %d", c.name, hll.histogram[k], k, histogram[k])
+			}
+		}
+	}
+}
@@ -2,8 +2,10 @@ package cardinality_test
 
 import (
 	"cardinality"
+	"fmt"
+	"math"
+	"math/rand"
 	"testing"
-
 )
 
 func TestHLLSanity(t *testing.T) {
@@ -35,3 +37,182 @@ func TestShiftedNlz64(t *testing.T) {
 		t.Errorf("expected %d, but got %d", 27, s_nlz)
 	}
 }
+
+func relErr(est int64, n int) float64 {
+	return math.Abs(float64(est)-float64(n)) / float64(n)
+}
+
+func TestHLLSparse(t *testing.T) {
+	hll, _ := cardinality.MakeHLL(14)
+	if est := hll.Estimate(); est != 0 {
+		t.Errorf("empty sketch should estimate 0, but was %d", est)
+	}
+	n := 1000
+	for i := 0; i < n; i++ {
+		hll.Observe([]byte(fmt.Sprintf("item-%d", i)))
+		hll.Observe([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	// linear counting at the sparse precision is close to exact at this size
+	if e := relErr(hll.Estimate(), n); e > 0.005 {
+		t.Errorf("sparse relative error %f > 0.005", e)
+	}
+}
+
+func TestHLLSparseToDense(t *testing.T) {
+	p := uint64(12)
+	hll, _ := cardinality.MakeHLL(p)
+	n := 100000
+	for i := 0; i < n; i++ {
+		hll.Observe([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	if e := relErr(hll.Estimate(), n); e > 3*1.04/math.Sqrt(float64(uint64(1)<<p)) {
+		t.Errorf("dense relative error %f is more than 3 standard errors", e)
+	}
+}
+
+func TestHLLBiasCorrection(t *testing.T) {
+	// around 1.5m the raw estimate is ~10% too high, and linear counting is past its threshold
+	p := uint64(10)
+	n := 1536
+	runs := 50
+	rng := rand.New(rand.NewSource(42))
+	sum := 0.0
+	for r := 0; r < runs; r++ {
+		hll, _ := cardinality.MakeHLL(p)
+		for i := 0; i < n; i++ {
+			hll.ObserveHash(uint64(rng.Int63())<<1 ^ uint64(rng.Int63()))
+		}
+		sum += float64(hll.Estimate()-int64(n)) / float64(n)
+	}
+	if bias := sum / float64(runs); math.Abs(bias) > 0.01 {
+		t.Errorf("mean relative bias %f > 0.01", bias)
+	}
+}
+
+func observeRange(hll *cardinality.HLL, from int, to int) {
+	for i := from; i < to; i++ {
+		hll.Observe([]byte(fmt.Sprintf("item-%d", i)))
+	}
+}
+
+func TestHLLMerge(t *testing.T) {
+	p := uint64(12)
+	for _, n := range []int{200, 100000} {
+		a, _ := cardinality.MakeHLL(p)
+		b, _ := cardinality.MakeHLL(p)
+		all, _ := cardinality.MakeHLL(p)
+		observeRange(a, 0, n*3/5)
+		observeRange(b, n*2/5, n)
+		observeRange(all, 0, n)
+
+		union, _ := a.Union(b)
+		if err := a.Merge(b); err != nil {
+			t.Fatal(err)
+		}
+		if a.Estimate() != all.Estimate() || union.Estimate() != all.Estimate() {
+			t.Errorf("merged estimate %d and union %d should equal the estimate %d of the whole set",
+				a.Estimate(), union.Estimate(), all.Estimate())
+		}
+	}
+}
+
+func TestHLLUnionDoesNotMutate(t *testing.T) {
+	a, _ := cardinality.MakeHLL(10)
+	b, _ := cardinality.MakeHLL(10)
+	observeRange(a, 0, 5000)
+	observeRange(b, 5000, 10000)
+	before := a.Estimate()
+	if _, err := a.Union(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Estimate() != before {
+		t.Errorf("union changed its receiver from %d to %d", before, a.Estimate())
+	}
+}
+
+// sketches above SPARSE_P start out dense, and fold back to the sparse form of a sketch that
+// observed the items at the lower precision
+func TestHLLMergeFold(t *testing.T) {
+	for _, c := range []struct{ lowP, highP uint64 }{{10, 14}, {23, 26}} {
+		for _, n := range []int{1, 10, 50, 100000} {
+			low, _ := cardinality.MakeHLL(c.lowP)
+			high, _ := cardinality.MakeHLL(c.highP)
+			observeRange(low, 0, n)
+			observeRange(high, 0, n)
+
+			empty, _ := cardinality.MakeHLL(c.lowP)
+			folded, _ := high.Union(empty)
+			if folded.Estimate() != low.Estimate() {
+				t.Errorf("n=%d: folding p=%d down to %d estimated %d, but p=%d sketch estimated %d",
+					n, c.highP, c.lowP, folded.Estimate(), c.lowP, low.Estimate())
+			}
+
+			// merging into the higher precision sketch folds the receiver
+			if err := high.Merge(empty); err != nil {
+				t.Fatal(err)
+			}
+			if high.Estimate() != low.Estimate() {
+				t.Errorf("n=%d: merged estimate %d, expected %d", n, high.Estimate(), low.Estimate())
+			}
+		}
+	}
+}
+
+// linear counting rounds to the nearest count in dense sketches too
+func TestHLLDenseSmallCounts(t *testing.T) {
+	for _, n := range []int{1, 10} {
+		hll, _ := cardinality.MakeHLL(26)
+		observeRange(hll, 0, n)
+		if est := hll.Estimate(); est != int64(n) {
+			t.Errorf("dense estimate of %d items was %d", n, est)
+		}
+	}
+}
+
+func TestHLLMergeHashMismatch(t *testing.T) {
+	a, _ := cardinality.MakeHLLWithHash(10, cardinality.HASH_FNV64)
+	b, _ := cardinality.MakeHLLWithHash(10, cardinality.HASH_FNV64A)
+	if err := a.Merge(b); err != cardinality.HashMismatchError {
+		t.Errorf("expected HashMismatchError, but was %v", err)
+	}
+	if _, err := cardinality.MakeHLLWithHash(10, 0); err != cardinality.InvalidHashError {
+		t.Errorf("expected InvalidHashError, but was %v", err)
+	}
+}
+
+func denseBenchHLL(b *testing.B) (*cardinality.HLL, [][]byte) {
+	hll, _ := cardinality.MakeHLL(16)
+	items := benchItems(1 << 20)
+	for _, item := range items {
+		hll.Observe(item)
+	}
+	b.ResetTimer()
+	return hll, items
+}
+
+func BenchmarkHLLObserve(b *testing.B) {
+	hll, items := denseBenchHLL(b)
+	for i := 0; i < b.N; i++ {
+		hll.Observe(items[i&(len(items)-1)])
+	}
+}
+
+func benchmarkEstimate(b *testing.B, e cardinality.Estimator) {
+	hll, _ := denseBenchHLL(b)
+	hll.SetEstimator(e)
+	for i := 0; i < b.N; i++ {
+		hll.Estimate()
+	}
+}
+
+func BenchmarkHLLEstimatePlusPlus(b *testing.B) {
+	benchmarkEstimate(b, cardinality.HLL_PLUS_PLUS)
+}
+
+func BenchmarkHLLEstimateImprovedRaw(b *testing.B) {
+	benchmarkEstimate(b, cardinality.IMPROVED_RAW)
+}
+
+func BenchmarkHLLEstimateMaxLikelihood(b *testing.B) {
+	benchmarkEstimate(b, cardinality.MAX_LIKELIHOOD)
+}
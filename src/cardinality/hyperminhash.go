@@ -0,0 +1,128 @@
+package cardinality
+
+import (
+	"errors"
+	"math"
+)
+
+var InvalidRError = errors.New("r has to be between 1 and 16")
+
+// HyperMinHash, from "HyperMinHash: MinHash in LogLog space" (Yu, Weber)
+// every register holds the HLL rank of its bucket's smallest hash, followed by r more
+// bits of that hash. the ranks give HLL cardinality estimates, and registers that match
+// between two sketches estimate the jaccard similarity the way MinHash does.
+type HyperMinHash struct {
+	registers []uint32 // rank << r | (max mantissa - mantissa), so the max keeps the smallest hash
+	m         uint64
+	p         uint64
+	r         uint64
+	hashID    HashID
+}
+
+func MakeHyperMinHash(p uint64, r uint64) (*HyperMinHash, error) {
+	return MakeHyperMinHashWithHash(p, r, HASH_FNV64)
+}
+
+func MakeHyperMinHashWithHash(p uint64, r uint64, hashID HashID) (*HyperMinHash, error) {
+	if p < 4 || p > 32 {
+		return nil, InvalidP32Error
+	}
+	if r < 1 || r > 16 {
+		return nil, InvalidRError
+	}
+	if _, ok := hashFns[hashID]; !ok {
+		return nil, InvalidHashError
+	}
+	m := uint64(1) << p
+	return &HyperMinHash{make([]uint32, m, m), m, p, r, hashID}, nil
+}
+
+func (hmh *HyperMinHash) Observe(d []byte) {
+	hmh.ObserveHash(hashData(hmh.hashID, d))
+}
+
+// the mantissa comes from the lowest r bits, the rank from the bits between them and the index
+func (hmh *HyperMinHash) ObserveHash(hash uint64) {
+	j := hash >> (64 - hmh.p)
+	rank := uint32(Nlz64(hash<<hmh.p|1<<(hmh.p+hmh.r-1)) + 1)
+	mask := uint32(1)<<hmh.r - 1
+	v := rank<<hmh.r | (mask - uint32(hash)&mask)
+	if hmh.registers[j] < v {
+		hmh.registers[j] = v
+	}
+}
+
+// an HLL holding the ranks of the registers
+func (hmh *HyperMinHash) toHLL() *HLL {
+	hll := makeDenseHLL(hmh.p, hmh.hashID)
+	for j, v := range hmh.registers {
+		hll.raise(uint64(j), int32(v>>hmh.r))
+	}
+	return hll
+}
+
+func (hmh *HyperMinHash) Estimate() int64 {
+	return hmh.toHLL().Estimate()
+}
+
+func (hmh *HyperMinHash) compatible(other *HyperMinHash) error {
+	if hmh.hashID != other.hashID {
+		return HashMismatchError
+	}
+	if hmh.p != other.p || hmh.r != other.r {
+		return PrecisionMismatchError
+	}
+	return nil
+}
+
+func (hmh *HyperMinHash) Merge(other *HyperMinHash) error {
+	if err := hmh.compatible(other); err != nil {
+		return err
+	}
+	for j, v := range other.registers {
+		if hmh.registers[j] < v {
+			hmh.registers[j] = v
+		}
+	}
+	return nil
+}
+
+// estimates the jaccard similarity, with one standard error. a fraction j of the registers
+// match because they hold a shared item, and of the rest a fraction c matches by chance:
+// independent minima of sets of size a and b fall into the same bin with probability about
+// c = 1/(2 ln 2) * 2^-r * ab/(a+b)^2
+func (hmh *HyperMinHash) Jaccard(other *HyperMinHash) (float64, float64, error) {
+	if err := hmh.compatible(other); err != nil {
+		return 0, 0, err
+	}
+	matches, nonEmpty := 0.0, 0.0
+	for j, v := range hmh.registers {
+		w := other.registers[j]
+		if v != 0 || w != 0 {
+			nonEmpty++
+			if v == w {
+				matches++
+			}
+		}
+	}
+	if nonEmpty == 0 {
+		return 0, 0, nil
+	}
+	a, b := float64(hmh.Estimate()), float64(other.Estimate())
+	c := 1 / (2 * math.Ln2) * math.Pow(2, -float64(hmh.r)) * a * b / ((a + b) * (a + b))
+	j := math.Max(0, math.Min(1, (matches/nonEmpty-c)/(1-c)))
+	return j, math.Sqrt(j * (1 - j) / nonEmpty), nil
+}
+
+// estimates |A ∩ B| as jaccard * |A ∪ B|, with one standard error
+func (hmh *HyperMinHash) Intersection(other *HyperMinHash) (int64, float64, error) {
+	j, jErr, err := hmh.Jaccard(other)
+	if err != nil {
+		return 0, 0, err
+	}
+	union := hmh.toHLL()
+	union.Merge(other.toHLL())
+	u := float64(union.Estimate())
+	bound := math.Sqrt(math.Pow(jErr*u, 2) + math.Pow(j*u*union.RelativeError(), 2))
+	return int64(j*u + 0.5), bound, nil
+}
@@ -0,0 +1,74 @@
+package cardinality_test
+
+import (
+	"cardinality"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func observeHMH(hmh *cardinality.HyperMinHash, from int, to int) {
+	for i := from; i < to; i++ {
+		hmh.Observe([]byte(fmt.Sprintf("item-%d", i)))
+	}
+}
+
+func TestHyperMinHashJaccard(t *testing.T) {
+	for _, c := range overlapCases {
+		a, _ := cardinality.MakeHyperMinHash(14, 10)
+		b, _ := cardinality.MakeHyperMinHash(14, 10)
+		observeHMH(a, 0, c.n)
+		observeHMH(b, c.n-c.overlap, 2*c.n-c.overlap)
+
+		j, jBound, err := a.Jaccard(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := float64(c.overlap) / float64(2*c.n-c.overlap)
+		if math.Abs(j-actual) > 3*jBound+0.001 {
+			t.Errorf("overlap %d: jaccard estimate %f is more than 3 * %f off %f", c.overlap, j, jBound, actual)
+		}
+
+		est, bound, _ := a.Intersection(b)
+		if math.Abs(float64(est-int64(c.overlap))) > 3*bound+50 {
+			t.Errorf("overlap %d: intersection estimate %d is more than 3 * %f off", c.overlap, est, bound)
+		}
+	}
+}
+
+func TestHyperMinHashSmallOverlap(t *testing.T) {
+	// inclusion-exclusion error scales with the sets, here ~1500, and swamps an overlap of 1000
+	n, overlap := 100000, 1000
+	a, _ := cardinality.MakeHyperMinHash(14, 10)
+	b, _ := cardinality.MakeHyperMinHash(14, 10)
+	observeHMH(a, 0, n)
+	observeHMH(b, n-overlap, 2*n-overlap)
+
+	est, _, _ := a.Intersection(b)
+	if e := math.Abs(float64(est)-float64(overlap)) / float64(overlap); e > 0.25 {
+		t.Errorf("intersection estimate %d of %d has relative error %f > 0.25", est, overlap, e)
+	}
+}
+
+func TestHyperMinHashCardinality(t *testing.T) {
+	a, _ := cardinality.MakeHyperMinHash(12, 8)
+	n := 100000
+	observeHMH(a, 0, n)
+	if e := relErr(a.Estimate(), n); e > 3*1.04/64 {
+		t.Errorf("relative error %f is more than 3 standard errors", e)
+	}
+}
+
+func TestHyperMinHashMismatch(t *testing.T) {
+	a, _ := cardinality.MakeHyperMinHash(10, 8)
+	b, _ := cardinality.MakeHyperMinHash(10, 6)
+	if _, _, err := a.Jaccard(b); err != cardinality.PrecisionMismatchError {
+		t.Errorf("expected PrecisionMismatchError, but was %v", err)
+	}
+	if _, err := cardinality.MakeHyperMinHash(10, 0); err != cardinality.InvalidRError {
+		t.Errorf("expected InvalidRError, but was %v", err)
+	}
+	if _, err := cardinality.MakeHyperMinHash(33, 8); err != cardinality.InvalidP32Error {
+		t.Errorf("expected InvalidP32Error, but was %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package cardinality
+
+import (
+	"math"
+)
+
+// standard error of an estimate, relative to the cardinality
+func (hll *HLL) RelativeError() float64 {
+	if hll.isSparse() {
+		return 1.04 / math.Sqrt(float64(uint64(1)<<SPARSE_P))
+	}
+	return 1.04 / math.Sqrt(float64(hll.m))
+}
+
+// estimates |A ∩ B| by inclusion-exclusion, |A| + |B| - |A ∪ B|. the returned bound is one
+// standard error, assuming the errors of the three estimates are independent. it grows with
+// the size of the sets rather than of the intersection, so small overlaps of large sets are
+// better served by HyperMinHash
+func (hll *HLL) Intersection(other *HLL) (int64, float64, error) {
+	union, err := hll.Union(other)
+	if err != nil {
+		return 0, 0, err
+	}
+	est, bound := hll.intersection(other, union)
+	return est, bound, nil
+}
+
+// inclusion-exclusion with the union already built
+func (hll *HLL) intersection(other *HLL, union *HLL) (int64, float64) {
+	a, b, u := float64(hll.Estimate()), float64(other.Estimate()), float64(union.Estimate())
+	est := math.Max(0, math.Min(a+b-u, math.Min(a, b)))
+	bound := math.Sqrt(math.Pow(a*hll.RelativeError(), 2) +
+		math.Pow(b*other.RelativeError(), 2) +
+		math.Pow(u*union.RelativeError(), 2))
+	return int64(est + 0.5), bound
+}
+
+// estimates the jaccard similarity |A ∩ B| / |A ∪ B| by inclusion-exclusion, with one standard error
+func (hll *HLL) Jaccard(other *HLL) (float64, float64, error) {
+	union, err := hll.Union(other)
+	if err != nil {
+		return 0, 0, err
+	}
+	u := float64(union.Estimate())
+	if u == 0 {
+		return 0, 0, nil
+	}
+	inter, bound := hll.intersection(other, union)
+	j := float64(inter) / u
+	return j, math.Sqrt(math.Pow(bound/u, 2) + math.Pow(j*union.RelativeError(), 2)), nil
+}
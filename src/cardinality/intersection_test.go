@@ -0,0 +1,46 @@
+package cardinality_test
+
+import (
+	"cardinality"
+	"math"
+	"testing"
+)
+
+// sets of size n, overlapping in the first overlap items
+type overlapCase struct {
+	n       int
+	overlap int
+}
+
+var overlapCases = []overlapCase{{50000, 0}, {50000, 10000}, {50000, 25000}, {50000, 40000}, {50000, 50000}}
+
+func TestHLLIntersection(t *testing.T) {
+	for _, c := range overlapCases {
+		a, _ := cardinality.MakeHLL(14)
+		b, _ := cardinality.MakeHLL(14)
+		observeRange(a, 0, c.n)
+		observeRange(b, c.n-c.overlap, 2*c.n-c.overlap)
+
+		est, bound, err := a.Intersection(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(float64(est-int64(c.overlap))) > 3*bound {
+			t.Errorf("overlap %d: intersection estimate %d is more than 3 * %f off", c.overlap, est, bound)
+		}
+
+		j, jBound, _ := a.Jaccard(b)
+		actual := float64(c.overlap) / float64(2*c.n-c.overlap)
+		if math.Abs(j-actual) > 3*jBound {
+			t.Errorf("overlap %d: jaccard estimate %f is more than 3 * %f off %f", c.overlap, j, jBound, actual)
+		}
+	}
+}
+
+func TestHLLIntersectionHashMismatch(t *testing.T) {
+	a, _ := cardinality.MakeHLLWithHash(10, cardinality.HASH_FNV64)
+	b, _ := cardinality.MakeHLLWithHash(10, cardinality.HASH_FNV64A)
+	if _, _, err := a.Intersection(b); err != cardinality.HashMismatchError {
+		t.Errorf("expected HashMismatchError, but was %v", err)
+	}
+}
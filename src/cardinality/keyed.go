@@ -0,0 +1,164 @@
+package cardinality
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"sort"
+)
+
+var InvalidMemoryError = errors.New("memory budget has to fit at least 16 times the virtual registers of a key")
+var InvalidVirtualPError = errors.New("p has to be between 4 and 16")
+
+const golden64 uint64 = 0x9e3779b97f4a7c15
+
+// distinct counts per key in a fixed amount of memory, using virtual HyperLogLogs from
+// "Cardinality Estimation for Elephant Flows: A Compact Solution Based on Virtual Register
+// Sharing" (Xiao, Chen, Chen, Ling)
+// all keys share one array of m physical registers. a key's s virtual registers are scattered
+// over it by hashing, so every virtual register also sees noise from other keys. the noise is
+// spread evenly, so the estimate over all m registers tells how much of it to subtract:
+//
+//	n_key = m s / (m - s) * (n_virtual / s - n_all / m)
+//
+// keys with the highest estimates are tracked as candidates in a min-heap, refreshed whenever
+// an observation raises one of their registers, for superspreader queries
+type KeyedCardinality struct {
+	physical      *HLL
+	virtual       *HLL   // scratch for the registers of the key being estimated
+	p             uint64 // s = 2^p virtual registers per key
+	hashID        HashID
+	candidates    candidateHeap
+	maxCandidates int
+}
+
+type KeyEstimate struct {
+	Key      string
+	Estimate int64
+}
+
+type candidateHeap struct {
+	entries   []KeyEstimate
+	positions map[string]int
+}
+
+func (h candidateHeap) Len() int           { return len(h.entries) }
+func (h candidateHeap) Less(i, j int) bool { return h.entries[i].Estimate < h.entries[j].Estimate }
+func (h candidateHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.positions[h.entries[i].Key] = i
+	h.positions[h.entries[j].Key] = j
+}
+func (h *candidateHeap) Push(x interface{}) {
+	e := x.(KeyEstimate)
+	h.positions[e.Key] = len(h.entries)
+	h.entries = append(h.entries, e)
+}
+func (h *candidateHeap) Pop() interface{} {
+	e := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	delete(h.positions, e.Key)
+	return e
+}
+
+// memoryBytes bounds the physical registers, rounded down to a power of 2. every key gets 2^p
+// virtual registers, and up to numCandidates keys are tracked for TopN
+func MakeKeyedCardinality(memoryBytes int, p uint64, numCandidates int) (*KeyedCardinality, error) {
+	if p < 4 || p > 16 {
+		return nil, InvalidVirtualPError
+	}
+	physicalP := uint64(0)
+	for uint64(4)<<(physicalP+1) <= uint64(memoryBytes) {
+		physicalP++
+	}
+	if physicalP < p+4 || physicalP > 32 {
+		return nil, InvalidMemoryError
+	}
+	return &KeyedCardinality{
+		physical:      makeDenseHLL(physicalP, HASH_FNV64),
+		virtual:       makeDenseHLL(p, HASH_FNV64),
+		p:             p,
+		hashID:        HASH_FNV64,
+		candidates:    candidateHeap{nil, make(map[string]int)},
+		maxCandidates: numCandidates,
+	}, nil
+}
+
+// bytes used by the physical registers
+func (kc *KeyedCardinality) RegisterBytes() int {
+	return 4 * len(kc.physical.registers)
+}
+
+func (kc *KeyedCardinality) physicalIndex(keyHash uint64, i uint64) uint64 {
+	return mix64(keyHash^(i*golden64)) >> (64 - kc.physical.p)
+}
+
+func (kc *KeyedCardinality) Observe(key []byte, item []byte) {
+	keyHash := hashData(kc.hashID, key)
+	hash := hashData(kc.hashID, item)
+	j := kc.physicalIndex(keyHash, hash>>(64-kc.p))
+	rank := rho(hash, kc.p)
+	if maxRank := int32(64 - kc.physical.p + 1); rank > maxRank {
+		rank = maxRank
+	}
+	if kc.physical.registers[j] >= rank {
+		return
+	}
+	kc.physical.raise(j, rank)
+	if kc.maxCandidates > 0 {
+		kc.updateCandidate(key, kc.estimate(keyHash))
+	}
+}
+
+func (kc *KeyedCardinality) estimate(keyHash uint64) int64 {
+	virtual := kc.virtual
+	virtual.clearDense()
+	for i := uint64(0); i < uint64(1)<<kc.p; i++ {
+		virtual.raise(i, kc.physical.registers[kc.physicalIndex(keyHash, i)])
+	}
+	m, s := float64(kc.physical.m), float64(virtual.m)
+	est := m * s / (m - s) * (float64(virtual.Estimate())/s - float64(kc.physical.Estimate())/m)
+	return int64(math.Max(0, est) + 0.5)
+}
+
+func (kc *KeyedCardinality) Estimate(key []byte) int64 {
+	return kc.estimate(hashData(kc.hashID, key))
+}
+
+// key is only copied to a string when it becomes a candidate
+func (kc *KeyedCardinality) updateCandidate(key []byte, est int64) {
+	if i, ok := kc.candidates.positions[string(key)]; ok {
+		kc.candidates.entries[i].Estimate = est
+		heap.Fix(&kc.candidates, i)
+		return
+	}
+	if kc.candidates.Len() < kc.maxCandidates {
+		heap.Push(&kc.candidates, KeyEstimate{string(key), est})
+		return
+	}
+	if min := kc.candidates.entries[0]; est > min.Estimate {
+		delete(kc.candidates.positions, min.Key)
+		kc.candidates.entries[0] = KeyEstimate{string(key), est}
+		kc.candidates.positions[string(key)] = 0
+		heap.Fix(&kc.candidates, 0)
+	}
+}
+
+// the n candidate keys with the highest distinct counts, highest first, with fresh estimates
+func (kc *KeyedCardinality) TopN(n int) []KeyEstimate {
+	result := make([]KeyEstimate, 0, kc.candidates.Len())
+	for _, c := range kc.candidates.entries {
+		result = append(result, KeyEstimate{c.Key, kc.Estimate([]byte(c.Key))})
+	}
+	sort.Sort(byEstimate(result))
+	if n < len(result) {
+		result = result[:n]
+	}
+	return result
+}
+
+type byEstimate []KeyEstimate
+
+func (e byEstimate) Len() int           { return len(e) }
+func (e byEstimate) Less(i, j int) bool { return e[i].Estimate > e[j].Estimate }
+func (e byEstimate) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
@@ -0,0 +1,81 @@
+package cardinality_test
+
+import (
+	"cardinality"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestKeyedCardinality(t *testing.T) {
+	budget := 1 << 20
+	kc, err := cardinality.MakeKeyedCardinality(budget, 8, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kc.RegisterBytes() > budget {
+		t.Errorf("registers use %d bytes, more than the budget of %d", kc.RegisterBytes(), budget)
+	}
+
+	// 20000 sources with 1-20 destinations each, and 5 superspreaders
+	spreaders := map[string]int{"spreader-0": 40000, "spreader-1": 20000, "spreader-2": 10000, "spreader-3": 5000, "spreader-4": 3000}
+	for src := 0; src < 20000; src++ {
+		for dst := 0; dst <= src%20; dst++ {
+			kc.Observe([]byte(fmt.Sprintf("source-%d", src)), []byte(fmt.Sprintf("dest-%d", dst)))
+		}
+	}
+	for key, n := range spreaders {
+		for dst := 0; dst < n; dst++ {
+			// repeated observations don't count twice
+			kc.Observe([]byte(key), []byte(fmt.Sprintf("dest-%d", dst)))
+			kc.Observe([]byte(key), []byte(fmt.Sprintf("dest-%d", dst)))
+		}
+	}
+
+	for key, n := range spreaders {
+		if e := relErr(kc.Estimate([]byte(key)), n); e > 0.2 {
+			t.Errorf("%s: estimate %d has relative error %f > 0.2", key, kc.Estimate([]byte(key)), e)
+		}
+	}
+	if est := kc.Estimate([]byte("source-19")); math.Abs(float64(est-20)) > 200 {
+		t.Errorf("small key estimate %d is too far from 20", est)
+	}
+
+	top := kc.TopN(5)
+	if len(top) != 5 {
+		t.Fatalf("expected 5 keys, but got %d", len(top))
+	}
+	for i, k := range top {
+		if expected := fmt.Sprintf("spreader-%d", i); k.Key != expected {
+			t.Errorf("expected %s at position %d, but was %s (%d)", expected, i, k.Key, k.Estimate)
+		}
+	}
+}
+
+func TestKeyedCardinalityBudget(t *testing.T) {
+	if _, err := cardinality.MakeKeyedCardinality(1024, 8, 10); err != cardinality.InvalidMemoryError {
+		t.Errorf("expected InvalidMemoryError, but was %v", err)
+	}
+	if _, err := cardinality.MakeKeyedCardinality(1<<20, 17, 10); err != cardinality.InvalidVirtualPError {
+		t.Errorf("expected InvalidVirtualPError, but was %v", err)
+	}
+}
+
+// once a key is a candidate, raising its registers refreshes it in place
+func TestKeyedCardinalityNoAllocs(t *testing.T) {
+	kc, _ := cardinality.MakeKeyedCardinality(1<<20, 8, 1)
+	key := []byte("spreader")
+	items := make([][]byte, 10000)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("dest-%d", i))
+	}
+	kc.Observe(key, items[0])
+	i := 0
+	allocs := testing.AllocsPerRun(1000, func() {
+		i++
+		kc.Observe(key, items[i%len(items)])
+	})
+	if allocs != 0 {
+		t.Errorf("observe allocated %f times", allocs)
+	}
+}
@@ -0,0 +1,113 @@
+package cardinality
+
+import (
+	"errors"
+	"time"
+)
+
+var InvalidWindowError = errors.New("window has to be positive")
+
+// returns the current time, replaceable in tests
+type Clock func() time.Time
+
+// a rank observed by a register, and when
+type possibleMax struct {
+	t    int64 // unix nanos
+	rank int32
+}
+
+// Sliding HyperLogLog, from "Sliding HyperLogLog: Estimating cardinality in a data
+// stream over a sliding window" (Chabchoub, Hébrail)
+// instead of its max rank, every register keeps the list of future possible maxima (LFPM):
+// the ranks that are still the max of some window ending now or later. the list is ordered
+// by time with strictly decreasing ranks, since an older rank that is not larger than a newer
+// one can never be the max again.
+type SlidingHLL struct {
+	registers [][]possibleMax
+	p         uint64
+	maxWindow int64 // nanos; older entries are dropped
+	hashID    HashID
+	clock     Clock
+}
+
+// clock tells the time of Observe and Estimate, time.Now if it is nil
+func MakeSlidingHLL(p uint64, maxWindow time.Duration, clock Clock) (*SlidingHLL, error) {
+	if p < 4 || p > 32 {
+		return nil, InvalidP32Error
+	}
+	if maxWindow <= 0 {
+		return nil, InvalidWindowError
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	m := uint64(1) << p
+	return &SlidingHLL{make([][]possibleMax, m, m), p, int64(maxWindow), HASH_FNV64, clock}, nil
+}
+
+func (s *SlidingHLL) Observe(d []byte) {
+	s.ObserveAt(d, s.clock())
+}
+
+func (s *SlidingHLL) ObserveAt(d []byte, t time.Time) {
+	s.ObserveHashAt(hashData(s.hashID, d), t)
+}
+
+func (s *SlidingHLL) ObserveHashAt(hash uint64, t time.Time) {
+	j := hash >> (64 - s.p)
+	s.registers[j] = insertPossibleMax(s.registers[j], possibleMax{t.UnixNano(), rho(hash, s.p)}, s.maxWindow)
+}
+
+// adds pm to the list unless a newer entry has at least its rank, and removes the older
+// entries it makes redundant along with the ones past the window. observations can arrive
+// out of order
+func insertPossibleMax(lfpm []possibleMax, pm possibleMax, maxWindow int64) []possibleMax {
+	i := len(lfpm)
+	for i > 0 && lfpm[i-1].t > pm.t {
+		i--
+	}
+	// ranks decrease with time, so only the entry right after pm can dominate it, and the
+	// entries it dominates are right before it
+	if i < len(lfpm) && lfpm[i].rank >= pm.rank {
+		return lfpm
+	}
+	k := i
+	for k > 0 && lfpm[k-1].rank <= pm.rank {
+		k--
+	}
+	if i == len(lfpm) {
+		lfpm = append(lfpm[:k], pm)
+	} else {
+		lfpm = append(lfpm[:k], append([]possibleMax{pm}, lfpm[i:]...)...)
+	}
+
+	expired := 0
+	for lfpm[expired].t < lfpm[len(lfpm)-1].t-maxWindow {
+		expired++
+	}
+	return lfpm[expired:]
+}
+
+// estimates the number of distinct items observed in (now - window, now]. window is capped at
+// the max window of the sketch
+func (s *SlidingHLL) EstimateWindow(now time.Time, window time.Duration) int64 {
+	if int64(window) > s.maxWindow {
+		window = time.Duration(s.maxWindow)
+	}
+	from, to := now.UnixNano()-int64(window), now.UnixNano()
+	hll := makeDenseHLL(s.p, s.hashID)
+	for j, lfpm := range s.registers {
+		// ranks decrease with time, so the first entry inside the window is its max
+		for _, e := range lfpm {
+			if e.t > from && e.t <= to {
+				hll.raise(uint64(j), e.rank)
+				break
+			}
+		}
+	}
+	return hll.Estimate()
+}
+
+func (s *SlidingHLL) Estimate(window time.Duration) int64 {
+	return s.EstimateWindow(s.clock(), window)
+}
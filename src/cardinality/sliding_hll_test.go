@@ -0,0 +1,100 @@
+package cardinality_test
+
+import (
+	"cardinality"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestSlidingHLLWindow(t *testing.T) {
+	clock := &fakeClock{time.Unix(1000, 0)}
+	s, _ := cardinality.MakeSlidingHLL(12, 10*time.Minute, clock.Now)
+
+	// 1000 new items every second, plus a few hundred items seen every second
+	for sec := 0; sec < 600; sec++ {
+		clock.now = clock.now.Add(time.Second)
+		for i := 0; i < 1000; i++ {
+			s.Observe([]byte(fmt.Sprintf("item-%d-%d", sec, i)))
+		}
+		for i := 0; i < 300; i++ {
+			s.Observe([]byte(fmt.Sprintf("repeated-%d", i)))
+		}
+	}
+
+	maxErr := 3 * 1.04 / 64
+	for _, window := range []int{1, 10, 60, 300, 600} {
+		expected := window*1000 + 300
+		if e := relErr(s.Estimate(time.Duration(window)*time.Second), expected); e > maxErr {
+			t.Errorf("window %ds: relative error %f > %f", window, e, maxErr)
+		}
+	}
+}
+
+func TestSlidingHLLExpires(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s, _ := cardinality.MakeSlidingHLL(10, time.Minute, (&fakeClock{start}).Now)
+	for i := 0; i < 5000; i++ {
+		s.ObserveAt([]byte(fmt.Sprintf("old-%d", i)), start)
+	}
+	later := start.Add(2 * time.Minute)
+	for i := 0; i < 100; i++ {
+		s.ObserveAt([]byte(fmt.Sprintf("new-%d", i)), later)
+	}
+	if est := s.EstimateWindow(later, 30*time.Second); math.Abs(float64(est-100)) > 5 {
+		t.Errorf("expected about 100 items in the last 30s, but was %d", est)
+	}
+	if est := s.EstimateWindow(later, time.Hour); math.Abs(float64(est-100)) > 5 {
+		t.Errorf("window should be capped at 1 minute, but estimate was %d", est)
+	}
+}
+
+func TestSlidingHLLOutOfOrder(t *testing.T) {
+	start := time.Unix(1000, 0)
+	inOrder, _ := cardinality.MakeSlidingHLL(10, time.Hour, (&fakeClock{start}).Now)
+	shuffled, _ := cardinality.MakeSlidingHLL(10, time.Hour, (&fakeClock{start}).Now)
+	n := 20000
+	for i := 0; i < n; i++ {
+		inOrder.ObserveAt([]byte(fmt.Sprintf("item-%d", i)), start.Add(time.Duration(i)*time.Millisecond))
+	}
+	for i := n - 1; i >= 0; i-- {
+		shuffled.ObserveAt([]byte(fmt.Sprintf("item-%d", i)), start.Add(time.Duration(i)*time.Millisecond))
+	}
+	now := start.Add(time.Duration(n) * time.Millisecond)
+	for _, window := range []time.Duration{time.Second, 5 * time.Second, time.Minute} {
+		if a, b := inOrder.EstimateWindow(now, window), shuffled.EstimateWindow(now, window); a != b {
+			t.Errorf("window %v: in order estimate %d differs from out of order %d", window, a, b)
+		}
+	}
+}
+
+func TestSlidingHLLInvalid(t *testing.T) {
+	if _, err := cardinality.MakeSlidingHLL(33, time.Minute, nil); err != cardinality.InvalidP32Error {
+		t.Errorf("expected InvalidP32Error, but was %v", err)
+	}
+	if _, err := cardinality.MakeSlidingHLL(12, 0, nil); err != cardinality.InvalidWindowError {
+		t.Errorf("expected InvalidWindowError, but was %v", err)
+	}
+}
+
+func TestSlidingHLLDefaultClock(t *testing.T) {
+	s, err := cardinality.MakeSlidingHLL(12, time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		s.Observe([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	if est := s.Estimate(time.Minute); math.Abs(float64(est-100)) > 5 {
+		t.Errorf("estimate should be about 100, was %d", est)
+	}
+}
@@ -0,0 +1,201 @@
+package cardinality
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+var InvalidKError = errors.New("k has to be at least 16")
+
+const THETA_ENCODING_VERSION byte = 1
+
+// theta of a sketch that has kept every hash
+const THETA_MAX uint64 = math.MaxUint64
+
+// Theta sketch over K Minimum Values, as in "Theta Sketch Framework" (Dasgupta, Lang,
+// Rhodes, Thaler)
+// the sketch keeps every hash below theta, and theta drops to the k+1-th smallest hash
+// once more than k are kept. since the kept hashes are a uniform sample of the distinct items
+// at rate theta / 2^64, the estimate is their number divided by that rate. unlike HLL, sketches
+// sampled that way support intersection and difference: the result keeps the hashes below the
+// smaller theta that the set operation keeps.
+type ThetaSketch struct {
+	hashes map[uint64]struct{} // all below theta; trimmed back to k once there are 2k
+	theta  uint64
+	k      int
+	hashID HashID
+}
+
+func MakeThetaSketch(k int) (*ThetaSketch, error) {
+	return MakeThetaSketchWithHash(k, HASH_FNV64)
+}
+
+func MakeThetaSketchWithHash(k int, hashID HashID) (*ThetaSketch, error) {
+	if k < 16 {
+		return nil, InvalidKError
+	}
+	if _, ok := hashFns[hashID]; !ok {
+		return nil, InvalidHashError
+	}
+	return &ThetaSketch{make(map[uint64]struct{}), THETA_MAX, k, hashID}, nil
+}
+
+func (ts *ThetaSketch) Update(d []byte) {
+	ts.UpdateHash(hashData(ts.hashID, d))
+}
+
+func (ts *ThetaSketch) UpdateHash(hash uint64) {
+	if hash >= ts.theta {
+		return
+	}
+	ts.hashes[hash] = struct{}{}
+	if len(ts.hashes) >= 2*ts.k {
+		ts.trim()
+	}
+}
+
+// keeps the k smallest hashes and lowers theta to the next one
+func (ts *ThetaSketch) trim() {
+	if len(ts.hashes) <= ts.k {
+		return
+	}
+	sorted := ts.sortedHashes()
+	ts.theta = sorted[ts.k]
+	for _, h := range sorted[ts.k:] {
+		delete(ts.hashes, h)
+	}
+}
+
+func (ts *ThetaSketch) sortedHashes() []uint64 {
+	sorted := make([]uint64, 0, len(ts.hashes))
+	for h := range ts.hashes {
+		sorted = append(sorted, h)
+	}
+	sort.Sort(uint64s(sorted))
+	return sorted
+}
+
+type uint64s []uint64
+
+func (u uint64s) Len() int           { return len(u) }
+func (u uint64s) Less(i, j int) bool { return u[i] < u[j] }
+func (u uint64s) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+
+// the fraction of hash space below theta
+func (ts *ThetaSketch) Theta() float64 {
+	return float64(ts.theta) / POW_2_64
+}
+
+func (ts *ThetaSketch) Estimate() float64 {
+	return float64(len(ts.hashes)) / ts.Theta()
+}
+
+// bounds of an interval numStdDev standard deviations wide on either side of the estimate.
+// every kept hash is a distinct item, so the lower bound is at least their number
+func (ts *ThetaSketch) Bounds(numStdDev float64) (float64, float64) {
+	n, theta := float64(len(ts.hashes)), ts.Theta()
+	dev := numStdDev * math.Sqrt(n*(1-theta)) / theta
+	return math.Max(n, ts.Estimate()-dev), ts.Estimate() + dev
+}
+
+// builds a sketch of the hashes below the smaller theta of ts and other that keep says
+// belong to the result
+func (ts *ThetaSketch) combine(other *ThetaSketch, keep func(inTs bool, inOther bool) bool) (*ThetaSketch, error) {
+	if ts.hashID != other.hashID {
+		return nil, HashMismatchError
+	}
+	result, _ := MakeThetaSketchWithHash(ts.k, ts.hashID)
+	result.theta = ts.theta
+	if other.theta < result.theta {
+		result.theta = other.theta
+	}
+	for h := range ts.hashes {
+		if _, inOther := other.hashes[h]; h < result.theta && keep(true, inOther) {
+			result.hashes[h] = struct{}{}
+		}
+	}
+	for h := range other.hashes {
+		if _, inTs := ts.hashes[h]; h < result.theta && !inTs && keep(false, true) {
+			result.hashes[h] = struct{}{}
+		}
+	}
+	result.trim()
+	return result, nil
+}
+
+func (ts *ThetaSketch) Union(other *ThetaSketch) (*ThetaSketch, error) {
+	return ts.combine(other, func(inTs bool, inOther bool) bool { return true })
+}
+
+func (ts *ThetaSketch) Intersection(other *ThetaSketch) (*ThetaSketch, error) {
+	return ts.combine(other, func(inTs bool, inOther bool) bool { return inTs && inOther })
+}
+
+// items in ts but not in other
+func (ts *ThetaSketch) ANotB(other *ThetaSketch) (*ThetaSketch, error) {
+	return ts.combine(other, func(inTs bool, inOther bool) bool { return inTs && !inOther })
+}
+
+// layout, version 1: version, hash id, uvarint k, theta (8 bytes little endian), uvarint
+// number of hashes, then the sorted hashes as uvarint deltas
+func (ts *ThetaSketch) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	data := []byte{THETA_ENCODING_VERSION, byte(ts.hashID)}
+	data = append(data, buf[:binary.PutUvarint(buf, uint64(ts.k))]...)
+	binary.LittleEndian.PutUint64(buf, ts.theta)
+	data = append(data, buf[:8]...)
+	data = append(data, buf[:binary.PutUvarint(buf, uint64(len(ts.hashes)))]...)
+	prev := uint64(0)
+	for _, h := range ts.sortedHashes() {
+		data = append(data, buf[:binary.PutUvarint(buf, h-prev)]...)
+		prev = h
+	}
+	return data, nil
+}
+
+// decodes data into ts. an initialized sketch rejects data encoded with a different hash
+func (ts *ThetaSketch) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return CorruptEncodingError
+	}
+	if data[0] != THETA_ENCODING_VERSION {
+		return EncodingVersionError
+	}
+	hashID := HashID(data[1])
+	if ts.hashes != nil && ts.hashID != hashID {
+		return HashMismatchError
+	}
+	data = data[2:]
+	k, read := binary.Uvarint(data)
+	if read <= 0 || k > math.MaxInt32 || len(data) < read+8 {
+		return CorruptEncodingError
+	}
+	decoded, err := MakeThetaSketchWithHash(int(k), hashID)
+	if err != nil {
+		return err
+	}
+	decoded.theta = binary.LittleEndian.Uint64(data[read:])
+	data = data[read+8:]
+	n, read := binary.Uvarint(data)
+	if read <= 0 || n > uint64(len(data)) {
+		return CorruptEncodingError
+	}
+	data = data[read:]
+	h := uint64(0)
+	for i := uint64(0); i < n; i++ {
+		delta, read := binary.Uvarint(data)
+		if read <= 0 || (i > 0 && delta == 0) || h+delta < h || h+delta >= decoded.theta {
+			return CorruptEncodingError
+		}
+		h += delta
+		decoded.hashes[h] = struct{}{}
+		data = data[read:]
+	}
+	if len(data) != 0 {
+		return CorruptEncodingError
+	}
+	*ts = *decoded
+	return nil
+}
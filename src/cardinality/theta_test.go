@@ -0,0 +1,90 @@
+package cardinality_test
+
+import (
+	"cardinality"
+	"fmt"
+	"testing"
+)
+
+func thetaOfRange(from int, to int) *cardinality.ThetaSketch {
+	ts, _ := cardinality.MakeThetaSketch(4096)
+	for i := from; i < to; i++ {
+		ts.Update([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	return ts
+}
+
+func checkThetaEstimate(t *testing.T, name string, ts *cardinality.ThetaSketch, expected int) {
+	lower, upper := ts.Bounds(3)
+	if float64(expected) < lower || float64(expected) > upper {
+		t.Errorf("%s: expected %d to be within [%f, %f], estimate was %f", name, expected, lower, upper, ts.Estimate())
+	}
+}
+
+func TestThetaExactWhenSmall(t *testing.T) {
+	ts := thetaOfRange(0, 1000)
+	ts.Update([]byte("item-0"))
+	if ts.Estimate() != 1000 {
+		t.Errorf("a sketch that kept every hash should be exact, but was %f", ts.Estimate())
+	}
+}
+
+func TestThetaEstimate(t *testing.T) {
+	for _, n := range []int{5000, 100000, 1000000} {
+		checkThetaEstimate(t, fmt.Sprintf("n=%d", n), thetaOfRange(0, n), n)
+	}
+}
+
+func TestThetaSetOperations(t *testing.T) {
+	// A = [0, 100000), B = [70000, 170000), C = [150000, 200000)
+	a, b, c := thetaOfRange(0, 100000), thetaOfRange(70000, 170000), thetaOfRange(150000, 200000)
+
+	union, _ := a.Union(b)
+	checkThetaEstimate(t, "A union B", union, 170000)
+	inter, _ := a.Intersection(b)
+	checkThetaEstimate(t, "A intersect B", inter, 30000)
+	diff, _ := a.ANotB(b)
+	checkThetaEstimate(t, "A not B", diff, 70000)
+	diff, _ = b.ANotB(a)
+	checkThetaEstimate(t, "B not A", diff, 70000)
+
+	// results compose: (A union B) not C, and (A union B) intersect C
+	composed, _ := union.ANotB(c)
+	checkThetaEstimate(t, "(A union B) not C", composed, 150000)
+	composed, _ = union.Intersection(c)
+	checkThetaEstimate(t, "(A union B) intersect C", composed, 20000)
+
+	disjoint, _ := a.Intersection(c)
+	if disjoint.Estimate() != 0 {
+		t.Errorf("disjoint sets should have an empty intersection, but was %f", disjoint.Estimate())
+	}
+}
+
+func TestThetaRoundTrip(t *testing.T) {
+	a, b := thetaOfRange(0, 100000), thetaOfRange(70000, 170000)
+	inter, _ := a.Intersection(b)
+	for _, ts := range []*cardinality.ThetaSketch{a, inter, thetaOfRange(0, 10)} {
+		data, err := ts.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded cardinality.ThetaSketch
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Estimate() != ts.Estimate() || decoded.Theta() != ts.Theta() {
+			t.Errorf("estimate %f and theta %f changed to %f and %f", ts.Estimate(), ts.Theta(), decoded.Estimate(), decoded.Theta())
+		}
+		if err := decoded.UnmarshalBinary(data[:len(data)-1]); err != cardinality.CorruptEncodingError {
+			t.Errorf("expected CorruptEncodingError for truncated data, but was %v", err)
+		}
+	}
+}
+
+func TestThetaHashMismatch(t *testing.T) {
+	a, _ := cardinality.MakeThetaSketchWithHash(64, cardinality.HASH_FNV64)
+	b, _ := cardinality.MakeThetaSketchWithHash(64, cardinality.HASH_FNV64A)
+	if _, err := a.Union(b); err != cardinality.HashMismatchError {
+		t.Errorf("expected HashMismatchError, but was %v", err)
+	}
+}
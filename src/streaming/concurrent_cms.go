@@ -0,0 +1,192 @@
+package streaming
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// how many locks guard the cells of a decayed ConcurrentCountMin
+const CONCURRENT_STRIPES = 1024
+
+type cellKind uint8
+
+const (
+	// int64 counts, added atomically
+	intCells cellKind = iota
+	// float64 bits, added with a compare-and-swap loop
+	floatCells
+	// exponentially decayed weights with timestamps, under striped locks
+	expCells
+)
+
+// a count min sketch that many goroutines can update and read at once. hashing keeps no
+// state, and cells are laid out like CountMin's, with the stream total after them.
+// conservative update and Count-Mean-Min are not supported, since they read and write several
+// cells as one step
+type ConcurrentCountMin struct {
+	cells      []uint64
+	stamps     []int64 // expCells only
+	locks      []sync.Mutex
+	numBuckets uint32
+	k          uint32
+	seed       int64
+	kind       cellKind
+	decay      float64
+}
+
+func makeConcurrentCMS(eps float64, p_error float64, seed int64, kind cellKind, decay float64) *ConcurrentCountMin {
+	size, numHashes := estimate(eps, p_error)
+	numCells := int(size)*int(numHashes) + 1
+	c := &ConcurrentCountMin{
+		cells:      make([]uint64, numCells),
+		numBuckets: size,
+		k:          numHashes,
+		seed:       seed,
+		kind:       kind,
+		decay:      decay,
+	}
+	if kind == expCells {
+		c.stamps = make([]int64, numCells)
+		c.locks = make([]sync.Mutex, CONCURRENT_STRIPES)
+	}
+	return c
+}
+
+// weights are rounded to whole counts
+func MakeConcurrentCMS(eps float64, p_error float64, seed int64) *ConcurrentCountMin {
+	return makeConcurrentCMS(eps, p_error, seed, intCells, 0)
+}
+
+func MakeConcurrentFloatCMS(eps float64, p_error float64, seed int64) *ConcurrentCountMin {
+	return makeConcurrentCMS(eps, p_error, seed, floatCells, 0)
+}
+
+func MakeConcurrentExpCMS(eps float64, p_error float64, seed int64, decay float64) *ConcurrentCountMin {
+	return makeConcurrentCMS(eps, p_error, seed, expCells, decay)
+}
+
+func (c *ConcurrentCountMin) Seed() int64 {
+	return c.seed
+}
+
+func (c *ConcurrentCountMin) addCell(cell int, weight float64, updateTime time.Time) {
+	switch c.kind {
+	case intCells:
+		atomic.AddUint64(&c.cells[cell], uint64(wholeCount(weight)))
+	case floatCells:
+		for {
+			old := atomic.LoadUint64(&c.cells[cell])
+			if atomic.CompareAndSwapUint64(&c.cells[cell], old, math.Float64bits(math.Float64frombits(old)+weight)) {
+				return
+			}
+		}
+	case expCells:
+		now := updateTime.UnixNano()
+		lock := &c.locks[cell%CONCURRENT_STRIPES]
+		lock.Lock()
+		w := math.Float64frombits(c.cells[cell])
+		// we don't decay if it is 0
+		if w > 0.00000001 {
+			w *= math.Exp(c.decay * float64(now-c.stamps[cell]) / 1e9)
+		}
+		c.cells[cell] = math.Float64bits(w + weight)
+		c.stamps[cell] = now
+		lock.Unlock()
+	}
+}
+
+func (c *ConcurrentCountMin) readCell(cell int, readTime time.Time) float64 {
+	switch c.kind {
+	case intCells:
+		return float64(int64(atomic.LoadUint64(&c.cells[cell])))
+	case floatCells:
+		return math.Float64frombits(atomic.LoadUint64(&c.cells[cell]))
+	}
+	lock := &c.locks[cell%CONCURRENT_STRIPES]
+	lock.Lock()
+	w, stamp := math.Float64frombits(c.cells[cell]), c.stamps[cell]
+	lock.Unlock()
+	if w == 0 {
+		return 0
+	}
+	return math.Exp(c.decay*float64(readTime.UnixNano()-stamp)/1e9) * w
+}
+
+func (c *ConcurrentCountMin) Update(data []byte, weight float64) {
+	if c.kind != expCells {
+		c.UpdateT(data, weight, time.Time{})
+		return
+	}
+	c.UpdateT(data, weight, time.Now())
+}
+
+func (c *ConcurrentCountMin) UpdateT(data []byte, weight float64, updateTime time.Time) {
+	a, b := getHashParams(c.seed, data)
+	for i := uint32(0); i < c.k; i++ {
+		c.addCell(int(i*c.numBuckets+(a+b*i)%c.numBuckets), weight, updateTime)
+	}
+	c.addCell(len(c.cells)-1, math.Abs(weight), updateTime)
+}
+
+func (c *ConcurrentCountMin) Count(data []byte) (float64, error) {
+	if c.kind != expCells {
+		return c.CountT(data, time.Time{})
+	}
+	return c.CountT(data, time.Now())
+}
+
+func (c *ConcurrentCountMin) CountT(data []byte, readTime time.Time) (float64, error) {
+	if c.k == 0 {
+		return 0, ErrElementNotFound
+	}
+	a, b := getHashParams(c.seed, data)
+	min := MAX_FLOAT64
+	for i := uint32(0); i < c.k; i++ {
+		if cur := c.readCell(int(i*c.numBuckets+(a+b*i)%c.numBuckets), readTime); cur < min {
+			min = cur
+		}
+	}
+	return min, nil
+}
+
+// zeroes the cells one at a time, so updates made during the call may partly survive it
+func (c *ConcurrentCountMin) Reset() {
+	for cell := range c.cells {
+		if c.kind == expCells {
+			lock := &c.locks[cell%CONCURRENT_STRIPES]
+			lock.Lock()
+			c.cells[cell] = 0
+			lock.Unlock()
+		} else {
+			atomic.StoreUint64(&c.cells[cell], 0)
+		}
+	}
+}
+
+// copies the cells into a CountMin with the same dimensions and seed, plain or exponentially
+// decayed. cells are copied one at a time, so updates made during the call may be partly in
+// the copy
+func (c *ConcurrentCountMin) Snapshot() *CountMin {
+	decayMode := DECAY_NONE
+	if c.kind == expCells {
+		decayMode = DECAY_EXP
+	}
+	cms := makeCMS(c.numBuckets, c.k, c.seed, decayMode, c.decay, UPDATE_ALL, READ_MIN)
+	for cell := range c.cells {
+		switch c.kind {
+		case intCells, floatCells:
+			cms.weights[cell] = c.readCell(cell, time.Time{})
+		case expCells:
+			lock := &c.locks[cell%CONCURRENT_STRIPES]
+			lock.Lock()
+			cms.weights[cell] = math.Float64frombits(c.cells[cell])
+			if cms.weights[cell] != 0 {
+				cms.stamps[cell] = c.stamps[cell]
+			}
+			lock.Unlock()
+		}
+	}
+	return cms
+}
@@ -0,0 +1,160 @@
+package streaming_test
+
+import (
+	"fmt"
+	"math"
+	"streaming"
+	"sync"
+	"testing"
+	"time"
+)
+
+// every worker updates every key, so goroutines race on the same cells
+func updateConcurrent(cms streaming.CMSketch, workers int, numKeys int, weight float64) {
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < numKeys; i++ {
+				cms.Update(intToBuf(int32(i)), weight)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConcurrentCMSMatchesCMS(t *testing.T) {
+	workers, numKeys := 16, 2000
+	cms := streaming.MakeCMS(0.01, 0.01, 6)
+	for w := 0; w < workers; w++ {
+		for i := 0; i < numKeys; i++ {
+			cms.Update(intToBuf(int32(i)), 1)
+		}
+	}
+	for _, c := range []*streaming.ConcurrentCountMin{
+		streaming.MakeConcurrentCMS(0.01, 0.01, 6),
+		streaming.MakeConcurrentFloatCMS(0.01, 0.01, 6),
+	} {
+		updateConcurrent(c, workers, numKeys, 1)
+		snapshot := c.Snapshot()
+		for i := 0; i < numKeys; i++ {
+			want, _ := cms.Count(intToBuf(int32(i)))
+			got, _ := c.Count(intToBuf(int32(i)))
+			if got != want {
+				t.Fatalf("key %d: concurrent count %f, sequential %f", i, got, want)
+			}
+			if got, _ = snapshot.Count(intToBuf(int32(i))); got != want {
+				t.Fatalf("key %d: snapshot count %f, sequential %f", i, got, want)
+			}
+		}
+	}
+}
+
+func TestConcurrentExpCMS(t *testing.T) {
+	now := time.Now()
+	c := streaming.MakeConcurrentExpCMS(0.01, 0.01, 6, -0.1)
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.UpdateT([]byte("hello"), 1, now)
+			}
+		}()
+	}
+	wg.Wait()
+	later := now.Add(10 * time.Second)
+	want := 1600 * math.Exp(-1)
+	if cnt, _ := c.CountT([]byte("hello"), later); math.Abs(cnt-want) > 1e-6 {
+		t.Errorf("decayed count should be %f, was %f", want, cnt)
+	}
+	if cnt, _ := c.Snapshot().CountT([]byte("hello"), later); math.Abs(cnt-want) > 1e-6 {
+		t.Errorf("snapshot decayed count should be %f, was %f", want, cnt)
+	}
+}
+
+func TestConcurrentCMSCountWhileUpdating(t *testing.T) {
+	c := streaming.MakeConcurrentCMS(0.01, 0.01, 6)
+	done := make(chan bool)
+	go func() {
+		updateConcurrent(c, 4, 5000, 1)
+		close(done)
+	}()
+	prev := 0.0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		cnt, _ := c.Count(intToBuf(7))
+		if cnt < prev {
+			t.Errorf("count went down from %f to %f", prev, cnt)
+		}
+		prev = cnt
+	}
+}
+
+// splits b.N updates over a growing number of goroutines
+func benchmarkScaling(b *testing.B, makeSketch func() streaming.CMSketch) {
+	keys := benchKeys()
+	for _, workers := range []int{1, 2, 4, 8, 16, 32, 64} {
+		b.Run(fmt.Sprintf("goroutines-%d", workers), func(b *testing.B) {
+			cms := makeSketch()
+			var wg sync.WaitGroup
+			b.ResetTimer()
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := w; i < b.N; i += workers {
+						cms.Update(keys[i&(len(keys)-1)], 1)
+					}
+				}(w)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// a CountMin behind a mutex, for comparison
+type lockedCMS struct {
+	sync.Mutex
+	cms *streaming.CountMin
+}
+
+func (l *lockedCMS) Update(data []byte, weight float64) {
+	l.Lock()
+	l.cms.Update(data, weight)
+	l.Unlock()
+}
+
+func (l *lockedCMS) Count(data []byte) (float64, error) {
+	l.Lock()
+	defer l.Unlock()
+	return l.cms.Count(data)
+}
+
+func (l *lockedCMS) Reset() {
+	l.Lock()
+	l.cms.Reset()
+	l.Unlock()
+}
+
+func BenchmarkConcurrentCMSUpdate(b *testing.B) {
+	benchmarkScaling(b, func() streaming.CMSketch { return streaming.MakeConcurrentCMS(0.0001, 0.01, 0) })
+}
+
+func BenchmarkConcurrentFloatCMSUpdate(b *testing.B) {
+	benchmarkScaling(b, func() streaming.CMSketch { return streaming.MakeConcurrentFloatCMS(0.0001, 0.01, 0) })
+}
+
+func BenchmarkConcurrentExpCMSUpdate(b *testing.B) {
+	benchmarkScaling(b, func() streaming.CMSketch { return streaming.MakeConcurrentExpCMS(0.0001, 0.01, 0, -0.1) })
+}
+
+func BenchmarkMutexCMSUpdate(b *testing.B) {
+	benchmarkScaling(b, func() streaming.CMSketch { return &lockedCMS{cms: streaming.MakeCMS(0.0001, 0.01, 0)} })
+}
@@ -0,0 +1,150 @@
+package streaming
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrInvalidCounterBits = errors.New("cms: log counters have to be 8 or 16 bits")
+var ErrInvalidBase = errors.New("cms: log counter base has to be above 1")
+
+// bases that let the counters reach about 4e9 at 8 bits and 5e10 at 16 bits
+const CML8_BASE = 1.08
+const CML16_BASE = 1.00025
+
+// Count-Min-Log, from "Count-Min-Log sketch: Approximately counting with approximate counters"
+// (Pitel, Fouquier)
+// cells are Morris counters: a counter at c stands for (base^c - 1) / (base - 1), and going
+// from c to c+1 adds base^c to that. an update moves the smallest counter of the key up by
+// whole steps while the weight covers them and by one more step with probability of what is
+// left over the step, so decoded counters are unbiased. then, as in conservative update, the
+// key's other counters are raised to the new minimum. counters saturate at their max, and
+// weights below 0 are ignored since conservative update can't take them back
+type CountMinLog struct {
+	counters8  []uint8 // row after row, for 8 bit counters
+	counters16 []uint16
+	maxCounter int
+	base       float64
+	numBuckets uint32
+	k          uint32
+	seed       int64
+	rng        uint64
+	cells      []int
+}
+
+func MakeCMLDirect(size uint32, numHash uint32, bits uint, base float64, seed int64) (*CountMinLog, error) {
+	if !(base > 1) {
+		return nil, ErrInvalidBase
+	}
+	cml := &CountMinLog{base: base, numBuckets: size, k: numHash, seed: seed,
+		rng: uint64(seed), cells: make([]int, numHash)}
+	switch bits {
+	case 8:
+		cml.counters8 = make([]uint8, int(size)*int(numHash))
+		cml.maxCounter = math.MaxUint8
+	case 16:
+		cml.counters16 = make([]uint16, int(size)*int(numHash))
+		cml.maxCounter = math.MaxUint16
+	default:
+		return nil, ErrInvalidCounterBits
+	}
+	return cml, nil
+}
+
+func MakeCML8(eps float64, p_error float64, seed int64) *CountMinLog {
+	size, numHashes := estimate(eps, p_error)
+	cml, _ := MakeCMLDirect(size, numHashes, 8, CML8_BASE, seed)
+	return cml
+}
+
+func MakeCML16(eps float64, p_error float64, seed int64) *CountMinLog {
+	size, numHashes := estimate(eps, p_error)
+	cml, _ := MakeCMLDirect(size, numHashes, 16, CML16_BASE, seed)
+	return cml
+}
+
+func (cml *CountMinLog) Seed() int64 {
+	return cml.seed
+}
+
+// bytes used by the counters
+func (cml *CountMinLog) Bytes() int {
+	return len(cml.counters8) + 2*len(cml.counters16)
+}
+
+func (cml *CountMinLog) counter(cell int) int {
+	if cml.counters8 != nil {
+		return int(cml.counters8[cell])
+	}
+	return int(cml.counters16[cell])
+}
+
+func (cml *CountMinLog) setCounter(cell int, c int) {
+	if cml.counters8 != nil {
+		cml.counters8[cell] = uint8(c)
+	} else {
+		cml.counters16[cell] = uint16(c)
+	}
+}
+
+func (cml *CountMinLog) decode(c int) float64 {
+	if c == 0 {
+		return 0
+	}
+	return (math.Pow(cml.base, float64(c)) - 1) / (cml.base - 1)
+}
+
+// uniform in [0, 1), from a splitmix64 sequence
+func (cml *CountMinLog) random() float64 {
+	cml.rng += golden64
+	return float64(mix64(cml.rng)>>11) / (1 << 53)
+}
+
+func (cml *CountMinLog) minCounter() int {
+	min := cml.maxCounter
+	for _, cell := range cml.cells {
+		if c := cml.counter(cell); c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+func (cml *CountMinLog) Update(data []byte, weight float64) {
+	if !(weight > 0) || cml.k == 0 {
+		return
+	}
+	fillCells(cml.cells, cml.seed, cml.numBuckets, data)
+	c := cml.minCounter()
+	step := math.Pow(cml.base, float64(c))
+	for c < cml.maxCounter && weight >= step {
+		weight -= step
+		c++
+		step *= cml.base
+	}
+	if c < cml.maxCounter && cml.random()*step < weight {
+		c++
+	}
+	for _, cell := range cml.cells {
+		if cml.counter(cell) < c {
+			cml.setCounter(cell, c)
+		}
+	}
+}
+
+func (cml *CountMinLog) Count(data []byte) (float64, error) {
+	if cml.k == 0 {
+		return 0, ErrElementNotFound
+	}
+	fillCells(cml.cells, cml.seed, cml.numBuckets, data)
+	return cml.decode(cml.minCounter()), nil
+}
+
+func (cml *CountMinLog) Reset() {
+	for i := range cml.counters8 {
+		cml.counters8[i] = 0
+	}
+	for i := range cml.counters16 {
+		cml.counters16[i] = 0
+	}
+}
@@ -0,0 +1,136 @@
+package streaming_test
+
+import (
+	"fmt"
+	"math"
+	"streaming"
+	"testing"
+)
+
+func TestCMLSanity(t *testing.T) {
+	for _, cml := range []streaming.CMSketch{streaming.MakeCML8(0.01, 0.01, 2), streaming.MakeCML16(0.01, 0.01, 2)} {
+		k1 := []byte("hello")
+		if cnt, _ := cml.Count(k1); cnt != 0 {
+			t.Error("shouldn't be anything yet")
+		}
+		// the first step of a counter is exactly 1
+		cml.Update(k1, 1)
+		if cnt, _ := cml.Count(k1); cnt != 1 {
+			t.Errorf("should have 1, had %f", cnt)
+		}
+		cml.Update(k1, -5)
+		if cnt, _ := cml.Count(k1); cnt != 1 {
+			t.Errorf("negative weights should be ignored, had %f", cnt)
+		}
+		cml.Reset()
+		if cnt, _ := cml.Count(k1); cnt != 0 {
+			t.Errorf("reset should have made count be 0, instead was %f", cnt)
+		}
+	}
+}
+
+func TestCMLInvalid(t *testing.T) {
+	if _, err := streaming.MakeCMLDirect(10, 2, 12, 1.1, 0); err != streaming.ErrInvalidCounterBits {
+		t.Errorf("expected ErrInvalidCounterBits, got %v", err)
+	}
+	if _, err := streaming.MakeCMLDirect(10, 2, 8, 1, 0); err != streaming.ErrInvalidBase {
+		t.Errorf("expected ErrInvalidBase, got %v", err)
+	}
+}
+
+// counters move by random steps, but their decoded values are unbiased, so many keys counted
+// alone average to the true count
+func TestCMLUnbiased(t *testing.T) {
+	for _, bits := range []uint{8, 16} {
+		cml, _ := streaming.MakeCMLDirect(1<<16, 1, bits, 1.08, 4)
+		keys := benchKeys()[:1000]
+		for _, k := range keys {
+			for i := 0; i < 500; i++ {
+				cml.Update(k, 1)
+			}
+		}
+		sum := 0.0
+		for _, k := range keys {
+			cnt, _ := cml.Count(k)
+			sum += cnt
+		}
+		if mean := sum / float64(len(keys)); math.Abs(mean-500) > 10 {
+			t.Errorf("%d bits: mean count %f, should be near 500", bits, mean)
+		}
+	}
+}
+
+func TestCMLSaturates(t *testing.T) {
+	k1 := []byte("hello")
+	cml, _ := streaming.MakeCMLDirect(10, 2, 8, 1.5, 0)
+	cml.Update(k1, 1e300)
+	cnt, _ := cml.Count(k1)
+	if max := (math.Pow(1.5, 255) - 1) / 0.5; cnt != max {
+		t.Errorf("count should stop at %f, was %f", max, cnt)
+	}
+	// large weights take whole steps, so they stay close
+	cml.Reset()
+	cml.Update(k1, 1e6)
+	if cnt, _ := cml.Count(k1); math.Abs(cnt-1e6) > 0.5*1e6 {
+		t.Errorf("count should be near 1e6, was %f", cnt)
+	}
+}
+
+// CountMin takes 2 / eps float64 cells per row, and 4 rows for an error probability of 1/16
+func budgetSketches(bytes int) map[string]streaming.CMSketch {
+	eps := 2 / float64(bytes/32)
+	cml8, _ := streaming.MakeCMLDirect(uint32(bytes/4), 4, 8, streaming.CML8_BASE, 0)
+	cml16, _ := streaming.MakeCMLDirect(uint32(bytes/8), 4, 16, streaming.CML16_BASE, 0)
+	return map[string]streaming.CMSketch{
+		"CountMin":   streaming.MakeCMS(eps, 1.0/16, 0),
+		"CountMinCU": streaming.MakeCMSWithModes(eps, 1.0/16, 0, streaming.UPDATE_CONSERVATIVE, streaming.READ_MIN),
+		"CML8":       cml8,
+		"CML16":      cml16,
+	}
+}
+
+// with 4 and 8 times the cells of CountMin in the same memory, collisions cost less than the
+// counters lose
+func TestCMLAccuracyPerByte(t *testing.T) {
+	sketches := budgetSketches(1 << 14)
+	plain := zipfError(sketches["CountMinCU"])
+	for _, name := range []string{"CML8", "CML16"} {
+		if e := zipfError(sketches[name]); e >= plain {
+			t.Errorf("%s mean error %f in 16KB, CountMin with conservative update %f", name, e, plain)
+		}
+	}
+}
+
+func BenchmarkCMLAccuracyPerByte(b *testing.B) {
+	for _, bytes := range []int{1 << 12, 1 << 14, 1 << 16} {
+		sketches := budgetSketches(bytes)
+		for _, name := range []string{"CountMin", "CountMinCU", "CML8", "CML16"} {
+			cms := sketches[name]
+			b.Run(fmt.Sprintf("%s/%dB", name, bytes), func(b *testing.B) {
+				var e float64
+				for i := 0; i < b.N; i++ {
+					cms.Reset()
+					e = zipfError(cms)
+				}
+				b.ReportMetric(e, "abs-err/key")
+				b.ReportMetric(float64(bytes), "bytes")
+			})
+		}
+	}
+}
+
+func BenchmarkCML8Update(b *testing.B) {
+	benchmarkUpdate(b, streaming.MakeCML8(0.0001, 0.01, 0))
+}
+
+func BenchmarkCML8Count(b *testing.B) {
+	benchmarkCount(b, streaming.MakeCML8(0.0001, 0.01, 0))
+}
+
+func BenchmarkCML16Update(b *testing.B) {
+	benchmarkUpdate(b, streaming.MakeCML16(0.0001, 0.01, 0))
+}
+
+func BenchmarkCML16Count(b *testing.B) {
+	benchmarkCount(b, streaming.MakeCML16(0.0001, 0.01, 0))
+}
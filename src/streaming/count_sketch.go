@@ -0,0 +1,90 @@
+package streaming
+
+import (
+	"math"
+)
+
+const golden64 uint64 = 0x9e3779b97f4a7c15
+
+// Count Sketch, from "Finding Frequent Items in Data Streams" (Charikar, Chen,
+// Farach-Colton)
+// every row adds the weight of a key to its cell times a random sign. other keys in the cell
+// cancel out on average, so a row's sign times the cell is an unbiased estimate, and the median
+// over the rows is within eps ||f||_2 with probability 1 - p_error. weights can be negative.
+// cells are picked with the same double hashing as CountMin, and signs come from mixing the
+// same hash
+type CountSketch struct {
+	cells      []float64 // row after row
+	numBuckets uint32
+	k          uint32
+	seed       int64
+	rows       []int
+	estimates  []float64
+}
+
+func MakeCountSketchDirect(size uint32, numHash uint32, seed int64) *CountSketch {
+	return &CountSketch{make([]float64, int(size)*int(numHash)), size, numHash, seed,
+		make([]int, numHash), make([]float64, numHash)}
+}
+
+// a row of 3 / eps^2 cells is off by more than eps ||f||_2 with probability at most 1/3
+func MakeCountSketch(eps float64, p_error float64, seed int64) *CountSketch {
+	_, numHashes := estimate(eps, p_error)
+	return MakeCountSketchDirect(uint32(math.Ceil(3/(eps*eps))), numHashes, seed)
+}
+
+func (cs *CountSketch) Seed() int64 {
+	return cs.seed
+}
+
+// the sign of the key in row i, from bit i of the mixed hash, remixed every 64 rows
+func sign(a uint32, b uint32, i int) float64 {
+	bits := mix64((uint64(b)<<32 | uint64(a)) + uint64(i/64+1)*golden64)
+	if bits>>uint(i%64)&1 == 1 {
+		return -1
+	}
+	return 1
+}
+
+func (cs *CountSketch) Update(data []byte, weight float64) {
+	a, b := getHashParams(cs.seed, data)
+	fillCells(cs.rows, cs.seed, cs.numBuckets, data)
+	for i, cell := range cs.rows {
+		cs.cells[cell] += sign(a, b, i) * weight
+	}
+}
+
+func (cs *CountSketch) Count(data []byte) (float64, error) {
+	if cs.k == 0 {
+		return 0, ErrElementNotFound
+	}
+	a, b := getHashParams(cs.seed, data)
+	fillCells(cs.rows, cs.seed, cs.numBuckets, data)
+	for i, cell := range cs.rows {
+		cs.estimates[i] = sign(a, b, i) * cs.cells[cell]
+	}
+	return median(cs.estimates), nil
+}
+
+// the AMS estimate of F2 = sum_x f(x)^2: every row's sum of squared cells is an unbiased
+// estimate, and the median over the rows is within a relative sqrt(2 / numBuckets) or so
+func (cs *CountSketch) F2() float64 {
+	if cs.k == 0 {
+		return 0
+	}
+	w := int(cs.numBuckets)
+	for row := range cs.estimates {
+		sum := 0.0
+		for _, c := range cs.cells[row*w : (row+1)*w] {
+			sum += c * c
+		}
+		cs.estimates[row] = sum
+	}
+	return median(cs.estimates)
+}
+
+func (cs *CountSketch) Reset() {
+	for i := range cs.cells {
+		cs.cells[i] = 0
+	}
+}
@@ -0,0 +1,83 @@
+package streaming_test
+
+import (
+	"math"
+	"math/rand"
+	"streaming"
+	"testing"
+)
+
+// a zipfian stream of increments followed by decrements of half of them, so net counts are
+// what is left of the heavy keys
+func deltaStream(t *testing.T) (*streaming.CountSketch, map[uint64]float64) {
+	items, _ := zipfStream(14, 100000)
+	var cs streaming.CMSketch = streaming.MakeCountSketch(0.05, 0.01, 3)
+	actual := make(map[uint64]float64)
+	r := rand.New(rand.NewSource(15))
+	for _, x := range items {
+		cs.Update(intToBuf(int32(x)), 1)
+		actual[x]++
+	}
+	for _, x := range items {
+		if r.Intn(2) == 0 {
+			cs.Update(intToBuf(int32(x)), -1)
+			actual[x]--
+		}
+	}
+	return cs.(*streaming.CountSketch), actual
+}
+
+func TestCountSketchNegativeWeights(t *testing.T) {
+	cs, actual := deltaStream(t)
+	l2, sumErr := 0.0, 0.0
+	for _, cnt := range actual {
+		l2 += cnt * cnt
+	}
+	l2 = math.Sqrt(l2)
+	misses := 0
+	for x, cnt := range actual {
+		est, err := cs.Count(intToBuf(int32(x)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(est-cnt) > 0.05*l2 {
+			misses++
+		}
+		sumErr += est - cnt
+	}
+	if misses > len(actual)/100 {
+		t.Errorf("%d of %d keys off by more than eps ||f||_2 = %f", misses, len(actual), 0.05*l2)
+	}
+	// unbiased, so the signed errors mostly cancel
+	if mean := sumErr / float64(len(actual)); math.Abs(mean) > 0.01*l2 {
+		t.Errorf("mean signed error %f, should be near 0", mean)
+	}
+}
+
+func TestCountSketchF2(t *testing.T) {
+	cs, actual := deltaStream(t)
+	exact := 0.0
+	for _, cnt := range actual {
+		exact += cnt * cnt
+	}
+	if est := cs.F2(); math.Abs(est-exact) > 0.05*exact {
+		t.Errorf("F2 estimate %f, exact %f", est, exact)
+	}
+}
+
+func TestCountSketchSanity(t *testing.T) {
+	cs := streaming.MakeCountSketchDirect(100, 5, 2)
+	k1 := []byte("hello")
+	cs.Update(k1, 10)
+	cs.Update(k1, -3)
+	if cnt, _ := cs.Count(k1); cnt != 7 {
+		t.Errorf("should have 7, had %f", cnt)
+	}
+	if f2 := cs.F2(); f2 != 49 {
+		t.Errorf("F2 should be 49, was %f", f2)
+	}
+	cs.Reset()
+	if cnt, _ := cs.Count(k1); cnt != 0 {
+		t.Errorf("reset should have made count be 0, instead was %f", cnt)
+	}
+}
@@ -2,33 +2,101 @@ package streaming
 
 import (
 	"errors"
-	"hash"
-	"hash/fnv"
 	"math"
+	"reflect"
+	"sort"
 	"time"
 )
 
 var ErrElementNotFound = errors.New("cms: element not found")
+var ErrIncompatibleSketches = errors.New("cms: sketches differ in dimensions, seed or decay")
 var LOWER32_MASK uint64 = ^uint64(0) >> 32
 var MAX_FLOAT64 float64 = math.Inf(1)
 
+const (
+	FNV64_OFFSET uint64 = 14695981039346656037
+	FNV64_PRIME  uint64 = 1099511628211
+)
+
 type UpdateFn func(elem *CMElement, update *CMUpdate) *CMElement
 type ReadFn func(elem *CMElement, readTime time.Time) float64
 
+// how UpdateT spreads an update over the rows
+type UpdateMode int
+
+const (
+	// every row's cell gets the full weight
+	UPDATE_ALL UpdateMode = iota
+	// cells are only raised up to the new min estimate, so keys sharing a cell with a heavy
+	// key stop inheriting all of its weight (Estan, Varghese). needs non-negative weights,
+	// negative ones are applied to every row as in UPDATE_ALL
+	UPDATE_CONSERVATIVE
+)
+
+// how CountT combines the rows
+type ReadMode int
+
+const (
+	// the smallest cell
+	READ_MIN ReadMode = iota
+	// Count-Mean-Min (Deng, Rafiei): every cell minus the noise expected from the rest of the
+	// stream, (total - cell) / (numBuckets - 1). the estimate is the median over the rows,
+	// capped by READ_MIN and floored at 0
+	READ_COUNT_MEAN_MIN
+)
+
+// the update and read functions of a sketch, by name, so an encoded sketch can restore them
+type DecayMode uint8
+
+const (
+	// Plain_update and Plain_read
+	DECAY_NONE DecayMode = iota
+	// exponential decay at the rate of the sketch
+	DECAY_EXP
+	// functions passed to MakeCMSDirect other than Plain_update and Plain_read, which the
+	// encoding can't name
+	DECAY_CUSTOM
+)
+
 type CMSketch interface {
 	Update(data []byte, weight float64)
 	Count(data []byte) (float64, error)
 	Reset()
 }
 
+// a sketch that can tell how far off its counts may be. with non-negative weights a count
+// overestimates by at most Epsilon() TotalWeight() with probability 1 - Delta()
+type BoundedCMSketch interface {
+	CMSketch
+	// est and an interval that holds the true count with probability 1 - Delta()
+	CountWithBounds(data []byte) (est float64, lower float64, upper float64)
+	Epsilon() float64
+	Delta() float64
+	// N, the L1 norm of the stream: the sum of |weight| over the updates
+	TotalWeight() float64
+}
+
 // implementation of CMS (count min sketch), optionally exponentially decayed
+// the cells are one flat array, row after row, followed by the total weight of the stream,
+// the sum of |weight|, which is kept like a cell so it decays like them. only sketches that decay keep a
+// timestamp per cell. the built-in decay modes work on the arrays directly; custom functions
+// get each cell copied into a CMElement owned by the sketch
 type CountMin struct {
-	matrix     [][]*CMElement
-	numBuckets uint32      // better to be prime
-	k          uint32      // num hashes
-	h          hash.Hash64 // hash to use, we use a + b * i, where i in (0,k], and a and b are obtained from hash h's upper 32 and lower 32 bits
+	weights    []float64
+	stamps     []int64 // unix nanos of each cell's last update, nil for DECAY_NONE
+	numBuckets uint32  // better to be prime
+	k          uint32  // num hashes
+	seed       int64   // selects the hash, we use a + b * i, where i in (0,k], and a and b are the upper 32 and lower 32 bits of seededHash
 	updateFn   UpdateFn
 	readFn     ReadFn
+	updateMode UpdateMode
+	readMode   ReadMode
+	decayMode  DecayMode
+	decay      float64   // rate of DECAY_EXP
+	cells      []int     // index of the key's cell in every row, for the call in progress
+	estimates  []float64 // per row estimates of READ_COUNT_MEAN_MIN
+	elem       CMElement // cell handed to custom functions
+	update     CMUpdate
 }
 
 type CMUpdate struct {
@@ -42,10 +110,6 @@ type CMElement struct {
 	Weight      float64
 }
 
-func (ce *CMElement) reset() {
-	ce.Weight = 0.0
-}
-
 func exp_decay(decay float64, prev time.Time, cur time.Time, weight float64) float64 {
 	return math.Exp(decay*(cur.Sub(prev).Seconds())) * weight
 }
@@ -77,25 +141,93 @@ func Plain_update(elem *CMElement, update *CMUpdate) *CMElement {
 	return elem
 }
 
+// the stamp of a cell that was never updated
+const NEVER_UPDATED int64 = math.MinInt64
+
+func stampOf(t time.Time) int64 {
+	if t.IsZero() {
+		return NEVER_UPDATED
+	}
+	return t.UnixNano()
+}
+
+func timeOf(stamp int64) time.Time {
+	if stamp == NEVER_UPDATED {
+		return time.Time{}
+	}
+	return time.Unix(0, stamp)
+}
+
 func MakeCMSDirect(size uint32, numHash uint32, seed int64, updateF UpdateFn, readF ReadFn) *CountMin {
-	mat := make([][]*CMElement, numHash)
-	for i, _ := range mat {
-		mat[i] = make([]*CMElement, size)
-		for j, _ := range mat[i] {
-			mat[i][j] = &CMElement{}
+	return MakeCMSDirectWithModes(size, numHash, seed, updateF, readF, UPDATE_ALL, READ_MIN)
+}
+
+// Plain_update with Plain_read makes the same sketch as MakeCMSWithModes, with no timestamps
+func MakeCMSDirectWithModes(size uint32, numHash uint32, seed int64, updateF UpdateFn, readF ReadFn,
+	updateMode UpdateMode, readMode ReadMode) *CountMin {
+	if sameFn(updateF, Plain_update) && sameFn(readF, Plain_read) {
+		return makeCMS(size, numHash, seed, DECAY_NONE, 0, updateMode, readMode)
+	}
+	cms := makeCMS(size, numHash, seed, DECAY_CUSTOM, 0, updateMode, readMode)
+	cms.updateFn, cms.readFn = updateF, readF
+	return cms
+}
+
+// funcs can't be compared, so this compares their code pointers
+func sameFn(a interface{}, b interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+func makeCMS(size uint32, numHash uint32, seed int64, decayMode DecayMode, decay float64,
+	updateMode UpdateMode, readMode ReadMode) *CountMin {
+	numCells := int(size)*int(numHash) + 1
+	cms := &CountMin{
+		weights:    make([]float64, numCells),
+		numBuckets: size,
+		k:          numHash,
+		seed:       seed,
+		updateMode: updateMode,
+		readMode:   readMode,
+		decayMode:  decayMode,
+		decay:      decay,
+		cells:      make([]int, numHash),
+		estimates:  make([]float64, numHash),
+	}
+	if decayMode != DECAY_NONE {
+		cms.stamps = make([]int64, numCells)
+		for i := range cms.stamps {
+			cms.stamps[i] = NEVER_UPDATED
 		}
 	}
-	return &CountMin{mat, size, numHash, fnv.New64(), updateF, readF}
+	cms.setDecayFns()
+	return cms
+}
+
+func (cms *CountMin) setDecayFns() {
+	switch cms.decayMode {
+	case DECAY_NONE:
+		cms.updateFn, cms.readFn = Plain_update, Plain_read
+	case DECAY_EXP:
+		cms.updateFn, cms.readFn = expUpdateFn_from_decay(cms.decay), expRead_from_decay(cms.decay)
+	}
 }
 
 func MakeCMS(eps float64, p_error float64, seed int64) *CountMin {
+	return MakeCMSWithModes(eps, p_error, seed, UPDATE_ALL, READ_MIN)
+}
+
+func MakeCMSWithModes(eps float64, p_error float64, seed int64, updateMode UpdateMode, readMode ReadMode) *CountMin {
 	size, numHashes := estimate(eps, p_error)
-	return MakeCMSDirect(size, numHashes, seed, Plain_update, Plain_read)
+	return makeCMS(size, numHashes, seed, DECAY_NONE, 0, updateMode, readMode)
 }
 
 func MakeExpCMS(eps float64, p_error float64, seed int64, decay float64) *CountMin {
+	return MakeExpCMSWithModes(eps, p_error, seed, decay, UPDATE_ALL, READ_MIN)
+}
+
+func MakeExpCMSWithModes(eps float64, p_error float64, seed int64, decay float64, updateMode UpdateMode, readMode ReadMode) *CountMin {
 	size, numHashes := estimate(eps, p_error)
-	return MakeCMSDirect(size, numHashes, seed, expUpdateFn_from_decay(decay), expRead_from_decay(decay))
+	return makeCMS(size, numHashes, seed, DECAY_EXP, decay, updateMode, readMode)
 }
 
 func estimate(eps float64, p_error float64) (size uint32, numHashes uint32) {
@@ -104,22 +236,86 @@ func estimate(eps float64, p_error float64) (size uint32, numHashes uint32) {
 	return
 }
 
-func (cms *CountMin) getHashParams(data []byte) (uint32, uint32) {
-	cms.h.Reset()
-	cms.h.Write(data)
-	sum := cms.h.Sum64()
+// murmur3's 64 bit finalizer
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// FNV-1 starting from an offset basis picked by the seed, so sketches with different seeds
+// hash independently and ones with the same seed put every key in the same cells. the
+// finalizer spreads the last bytes of the key over the whole hash
+func seededHash(seed int64, data []byte) uint64 {
+	h := FNV64_OFFSET ^ mix64(uint64(seed))
+	for _, c := range data {
+		h *= FNV64_PRIME
+		h ^= uint64(c)
+	}
+	return mix64(h)
+}
+
+func (cms *CountMin) Seed() int64 {
+	return cms.seed
+}
+
+func getHashParams(seed int64, data []byte) (uint32, uint32) {
+	sum := seededHash(seed, data)
 	lower := uint32(sum & LOWER32_MASK)
 	upper := uint32((sum >> 32) & LOWER32_MASK)
 	return lower, upper
 }
 
-func (cms *CountMin) getBuckets(data []byte) []uint32 {
-	buckets := make([]uint32, cms.k, cms.k)
-	a, b := cms.getHashParams(data)
-	for i := uint32(0); i < cms.k; i++ {
-		buckets[i] = (a + (b * i)) % cms.numBuckets
+// fills cells with the index of the key's cell in every row of a flat numBuckets x k array
+func fillCells(cells []int, seed int64, numBuckets uint32, data []byte) {
+	a, b := getHashParams(seed, data)
+	for i := range cells {
+		cells[i] = i*int(numBuckets) + int((a+b*uint32(i))%numBuckets)
 	}
-	return buckets
+}
+
+func (cms *CountMin) totalCell() int {
+	return len(cms.weights) - 1
+}
+
+func (cms *CountMin) readCell(cell int, readTime time.Time) float64 {
+	switch cms.decayMode {
+	case DECAY_NONE:
+		return cms.weights[cell]
+	case DECAY_EXP:
+		if cms.weights[cell] == 0 {
+			return 0
+		}
+		return math.Exp(cms.decay*float64(readTime.UnixNano()-cms.stamps[cell])/1e9) * cms.weights[cell]
+	}
+	cms.elem = CMElement{timeOf(cms.stamps[cell]), cms.weights[cell]}
+	return cms.readFn(&cms.elem, readTime)
+}
+
+// adds weight to the cell at updateTime, decaying what it held before
+func (cms *CountMin) addCell(cell int, key []byte, weight float64, updateTime time.Time) {
+	switch cms.decayMode {
+	case DECAY_NONE:
+		cms.weights[cell] += weight
+		return
+	case DECAY_EXP:
+		now := updateTime.UnixNano()
+		// we don't decay if it is 0
+		if cms.weights[cell] > 0.00000001 {
+			cms.weights[cell] *= math.Exp(cms.decay * float64(now-cms.stamps[cell]) / 1e9)
+		}
+		cms.weights[cell] += weight
+		cms.stamps[cell] = now
+		return
+	}
+	cms.elem = CMElement{timeOf(cms.stamps[cell]), cms.weights[cell]}
+	cms.update = CMUpdate{key, weight, updateTime}
+	result := cms.updateFn(&cms.elem, &cms.update)
+	cms.update.Key = nil
+	cms.weights[cell], cms.stamps[cell] = result.Weight, stampOf(result.Last_update)
 }
 
 // get estimated count
@@ -128,17 +324,48 @@ func (cms *CountMin) Count(data []byte) (float64, error) {
 }
 
 func (cms *CountMin) CountT(data []byte, readTime time.Time) (float64, error) {
+	fillCells(cms.cells, cms.seed, cms.numBuckets, data)
+	min := cms.minCount(readTime)
+	if min == MAX_FLOAT64 {
+		return 0, ErrElementNotFound
+	}
+	if cms.readMode == READ_COUNT_MEAN_MIN {
+		return math.Min(min, cms.countMeanMin(readTime)), nil
+	}
+	return min, nil
+}
+
+func (cms *CountMin) minCount(readTime time.Time) float64 {
 	min := MAX_FLOAT64
-	for i, b := range cms.getBuckets(data) {
-		cur := cms.readFn(cms.matrix[i][b], readTime)
+	for _, cell := range cms.cells {
+		cur := cms.readCell(cell, readTime)
 		if min > cur {
 			min = cur
 		}
 	}
-	if min == MAX_FLOAT64 {
-		return 0, ErrElementNotFound
+	return min
+}
+
+func (cms *CountMin) countMeanMin(readTime time.Time) float64 {
+	if cms.numBuckets < 2 {
+		return MAX_FLOAT64
 	}
-	return min, nil
+	total := cms.readCell(cms.totalCell(), readTime)
+	for i, cell := range cms.cells {
+		cur := cms.readCell(cell, readTime)
+		cms.estimates[i] = cur - (total-cur)/float64(cms.numBuckets-1)
+	}
+	return math.Max(0, median(cms.estimates))
+}
+
+// sorts values in place, and averages the middle two of an even number
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	m := values[len(values)/2]
+	if len(values)%2 == 0 {
+		m = (m + values[len(values)/2-1]) / 2
+	}
+	return m
 }
 
 // increments and returns estimated count so far
@@ -147,17 +374,96 @@ func (cms *CountMin) Update(data []byte, weight float64) {
 }
 
 func (cms *CountMin) UpdateT(data []byte, weight float64, updateTime time.Time) {
-	update := &CMUpdate{data, weight, updateTime}
-	for i, b := range cms.getBuckets(data) {
-		cur := cms.matrix[i][b]
-		cur = cms.updateFn(cur, update)
+	cms.addCell(cms.totalCell(), data, math.Abs(weight), updateTime)
+	fillCells(cms.cells, cms.seed, cms.numBuckets, data)
+	if cms.updateMode == UPDATE_CONSERVATIVE && weight >= 0 {
+		cms.conservativeUpdate(data, weight, updateTime)
+		return
+	}
+	for _, cell := range cms.cells {
+		cms.addCell(cell, data, weight, updateTime)
+	}
+}
+
+// raises every cell below the new estimate up to it, so decayed cells end up reading exactly
+// the new estimate at updateTime
+func (cms *CountMin) conservativeUpdate(data []byte, weight float64, updateTime time.Time) {
+	target := cms.minCount(updateTime) + weight
+	for _, cell := range cms.cells {
+		if cur := cms.readCell(cell, updateTime); cur < target {
+			cms.addCell(cell, data, target-cur, updateTime)
+		}
 	}
 }
 
 func (cms *CountMin) Reset() {
-	for _, row := range cms.matrix {
-		for _, elem := range row {
-			elem.reset()
+	for i := range cms.weights {
+		cms.weights[i] = 0.0
+	}
+}
+
+// adds other into cms cell by cell, so sketches of parts of a stream combine into a sketch of
+// all of it. both need the same dimensions, seed and decay. decayed cells are brought to the
+// later of their two update times before they are added
+func (cms *CountMin) Merge(other *CountMin) error {
+	if !cms.compatible(other) {
+		return ErrIncompatibleSketches
+	}
+	for cell := range cms.weights {
+		if other.weights[cell] == 0 {
+			continue
+		}
+		if cms.stamps == nil {
+			cms.weights[cell] += other.weights[cell]
+			continue
+		}
+		at := other.stamps[cell]
+		if cms.stamps[cell] > at {
+			at = cms.stamps[cell]
 		}
+		cms.addCell(cell, nil, other.readCell(cell, timeOf(at)), timeOf(at))
+	}
+	return nil
+}
+
+// whether other puts every key in the same cells and decays them the same way
+func (cms *CountMin) compatible(other *CountMin) bool {
+	return cms.numBuckets == other.numBuckets && cms.k == other.k && cms.seed == other.seed &&
+		cms.decayMode == other.decayMode && cms.decay == other.decay
+}
+
+// a row of numBuckets cells overcounts by N / numBuckets on average, so by more than
+// eps N = 2 N / numBuckets with probability at most 1/2
+func (cms *CountMin) Epsilon() float64 {
+	return 2 / float64(cms.numBuckets)
+}
+
+// the probability that every row overcounts by more than eps N
+func (cms *CountMin) Delta() float64 {
+	return math.Exp2(-float64(cms.k))
+}
+
+func (cms *CountMin) TotalWeight() float64 {
+	return cms.TotalWeightT(time.Now())
+}
+
+// decayed sketches decay the total like their cells
+func (cms *CountMin) TotalWeightT(readTime time.Time) float64 {
+	return cms.readCell(cms.totalCell(), readTime)
+}
+
+func (cms *CountMin) CountWithBounds(data []byte) (float64, float64, float64) {
+	return cms.CountWithBoundsT(data, time.Now())
+}
+
+// the true count is at most the smallest cell and, with probability 1 - Delta(), at least
+// that minus eps N. the estimate follows the read mode and lies in between
+func (cms *CountMin) CountWithBoundsT(data []byte, readTime time.Time) (float64, float64, float64) {
+	est, err := cms.CountT(data, readTime)
+	if err != nil {
+		return 0, 0, 0
 	}
+	upper := cms.minCount(readTime)
+	lower := math.Max(0, upper-cms.Epsilon()*cms.TotalWeightT(readTime))
+	return est, lower, upper
 }
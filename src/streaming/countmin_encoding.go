@@ -0,0 +1,191 @@
+package streaming
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrEncodingVersion = errors.New("cms: unknown encoding version")
+var ErrCorruptEncoding = errors.New("cms: corrupt encoding")
+var ErrCustomFunctions = errors.New("cms: sketch uses custom update and read functions, decode it into a sketch made with them")
+
+const CMS_ENCODING_VERSION byte = 1
+const WINDOWED_ENCODING_VERSION byte = 1
+
+// layout, version 1: version, decay mode, update mode, read mode, uvarint numBuckets, uvarint
+// k, seed and decay rate (8 bytes little endian each), then the stream total followed by the
+// cells row by row. every cell is its weight as float64 bits, followed by Last_update in unix
+// nanos unless the decay mode is DECAY_NONE. all cells have the same size, so the dimensions
+// fix the length of the encoding
+func (cms *CountMin) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	data := []byte{CMS_ENCODING_VERSION, byte(cms.decayMode), byte(cms.updateMode), byte(cms.readMode)}
+	data = append(data, buf[:binary.PutUvarint(buf, uint64(cms.numBuckets))]...)
+	data = append(data, buf[:binary.PutUvarint(buf, uint64(cms.k))]...)
+	binary.LittleEndian.PutUint64(buf, uint64(cms.seed))
+	data = append(data, buf[:8]...)
+	binary.LittleEndian.PutUint64(buf, math.Float64bits(cms.decay))
+	data = append(data, buf[:8]...)
+	data = cms.appendCell(data, cms.totalCell())
+	for cell := 0; cell < cms.totalCell(); cell++ {
+		data = cms.appendCell(data, cell)
+	}
+	return data, nil
+}
+
+func (cms *CountMin) cellSize() int {
+	if cms.decayMode == DECAY_NONE {
+		return 8
+	}
+	return 16
+}
+
+func (cms *CountMin) appendCell(data []byte, cell int) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(cms.weights[cell]))
+	data = append(data, buf[:]...)
+	if cms.stamps == nil {
+		return data
+	}
+	binary.LittleEndian.PutUint64(buf[:], uint64(cms.stamps[cell]))
+	return append(data, buf[:]...)
+}
+
+func (cms *CountMin) decodeCell(data []byte, cell int) {
+	cms.weights[cell] = math.Float64frombits(binary.LittleEndian.Uint64(data))
+	if cms.stamps != nil {
+		cms.stamps[cell] = int64(binary.LittleEndian.Uint64(data[8:]))
+	}
+}
+
+// decodes data into cms, restoring the built-in update and read functions by name. a sketch
+// encoded with custom functions can only be decoded into a sketch that already has them
+func (cms *CountMin) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return ErrCorruptEncoding
+	}
+	if data[0] != CMS_ENCODING_VERSION {
+		return ErrEncodingVersion
+	}
+	decayMode, updateMode, readMode := DecayMode(data[1]), UpdateMode(data[2]), ReadMode(data[3])
+	if decayMode > DECAY_CUSTOM || updateMode > UPDATE_CONSERVATIVE || readMode > READ_COUNT_MEAN_MIN {
+		return ErrCorruptEncoding
+	}
+	if decayMode == DECAY_CUSTOM && (cms.updateFn == nil || cms.readFn == nil) {
+		return ErrCustomFunctions
+	}
+	data = data[4:]
+	numBuckets, read := binary.Uvarint(data)
+	if read <= 0 || numBuckets == 0 || numBuckets > math.MaxUint32 {
+		return ErrCorruptEncoding
+	}
+	data = data[read:]
+	k, read := binary.Uvarint(data)
+	if read <= 0 || k > math.MaxUint32 || len(data) < read+16 {
+		return ErrCorruptEncoding
+	}
+	data = data[read:]
+	seed := int64(binary.LittleEndian.Uint64(data))
+	decay := math.Float64frombits(binary.LittleEndian.Uint64(data[8:]))
+	data = data[16:]
+
+	// check the length before allocating what a corrupt header asks for
+	decoded := &CountMin{decayMode: decayMode}
+	cellSize := uint64(decoded.cellSize())
+	if numBuckets*k >= uint64(len(data)) || uint64(len(data)) != (numBuckets*k+1)*cellSize {
+		return ErrCorruptEncoding
+	}
+	decoded = makeCMS(uint32(numBuckets), uint32(k), seed, decayMode, decay, updateMode, readMode)
+	if decayMode == DECAY_CUSTOM {
+		decoded.updateFn, decoded.readFn = cms.updateFn, cms.readFn
+	}
+	decoded.decodeCell(data, decoded.totalCell())
+	data = data[cellSize:]
+	for cell := 0; cell < decoded.totalCell(); cell++ {
+		decoded.decodeCell(data, cell)
+		data = data[cellSize:]
+	}
+	*cms = *decoded
+	return nil
+}
+
+// layout, version 1: version, uvarint slice length, uvarint counter, uvarint slice nanos,
+// varint current slice, uvarint number of slices, then every slice from the newest as a
+// uvarint length and its CountMin encoding
+func (w *WindowedCMS) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	data := []byte{WINDOWED_ENCODING_VERSION}
+	data = append(data, buf[:binary.PutUvarint(buf, uint64(w.limit))]...)
+	data = append(data, buf[:binary.PutUvarint(buf, uint64(w.Counter))]...)
+	data = append(data, buf[:binary.PutUvarint(buf, uint64(w.sliceNanos))]...)
+	data = append(data, buf[:binary.PutVarint(buf, w.current)]...)
+	data = append(data, buf[:binary.PutUvarint(buf, uint64(w.sketches.Len()))]...)
+	for e := w.sketches.Front(); e != nil; e = e.Next() {
+		sketch, err := e.Value.(*CountMin).MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, buf[:binary.PutUvarint(buf, uint64(len(sketch)))]...)
+		data = append(data, sketch...)
+	}
+	return data, nil
+}
+
+// decodes data into w. a sketch in time mode keeps the clock of w, or gets time.Now
+func (w *WindowedCMS) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return ErrCorruptEncoding
+	}
+	if data[0] != WINDOWED_ENCODING_VERSION {
+		return ErrEncodingVersion
+	}
+	data = data[1:]
+	corrupt := false
+	uvarint := func() int64 {
+		v, read := binary.Uvarint(data)
+		if read <= 0 || v > math.MaxInt64 {
+			corrupt = true
+			return 0
+		}
+		data = data[read:]
+		return int64(v)
+	}
+	limit, counter, sliceNanos := uvarint(), uvarint(), uvarint()
+	if corrupt {
+		return ErrCorruptEncoding
+	}
+	current, read := binary.Varint(data)
+	if read <= 0 {
+		return ErrCorruptEncoding
+	}
+	data = data[read:]
+	numSketches := uvarint()
+	if corrupt || (limit <= 0) == (sliceNanos <= 0) || counter > limit || numSketches == 0 || numSketches > int64(len(data)) {
+		return ErrCorruptEncoding
+	}
+	sketches := list.New()
+	for i := int64(0); i < numSketches; i++ {
+		size, read := binary.Uvarint(data)
+		if read <= 0 || size > uint64(len(data)-read) {
+			return ErrCorruptEncoding
+		}
+		sketch := &CountMin{}
+		if err := sketch.UnmarshalBinary(data[read : read+int(size)]); err != nil {
+			return err
+		}
+		sketches.PushBack(sketch)
+		data = data[read+int(size):]
+	}
+	if len(data) != 0 {
+		return ErrCorruptEncoding
+	}
+	clock := w.clock
+	if sliceNanos > 0 && clock == nil {
+		clock = time.Now
+	}
+	*w = WindowedCMS{sketches, counter, limit, sliceNanos, current, clock}
+	return nil
+}
@@ -0,0 +1,162 @@
+package streaming_test
+
+import (
+	"math"
+	"streaming"
+	"testing"
+	"time"
+)
+
+func roundTrip(t *testing.T, cms *streaming.CountMin) *streaming.CountMin {
+	data, err := cms.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &streaming.CountMin{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestCMSEncodingRoundTrip(t *testing.T) {
+	items, actual := zipfStream(5, 20000)
+	cms := streaming.MakeCMSWithModes(0.01, 0.01, 11, streaming.UPDATE_CONSERVATIVE, streaming.READ_COUNT_MEAN_MIN)
+	for _, x := range items {
+		cms.Update(intToBuf(int32(x)), 1)
+	}
+	decoded := roundTrip(t, cms)
+	if decoded.Seed() != 11 {
+		t.Errorf("seed should be 11, was %d", decoded.Seed())
+	}
+	for x := range actual {
+		want, _ := cms.Count(intToBuf(int32(x)))
+		if got, _ := decoded.Count(intToBuf(int32(x))); got != want {
+			t.Fatalf("key %d: decoded count %f, original %f", x, got, want)
+		}
+	}
+	// the update mode comes back too
+	k := intToBuf(1)
+	cms.Update(k, 5)
+	decoded.Update(k, 5)
+	want, _ := cms.Count(k)
+	if got, _ := decoded.Count(k); got != want {
+		t.Errorf("count after update should be %f, was %f", want, got)
+	}
+}
+
+func TestExpCMSEncodingRoundTrip(t *testing.T) {
+	now := time.Now()
+	k1 := []byte("hello")
+	cms := streaming.MakeExpCMS(0.01, 0.01, 3, -0.1)
+	cms.UpdateT(k1, 2.0, now)
+	decoded := roundTrip(t, cms)
+	later := now.Add(10 * time.Second)
+	want := 2 * math.Exp(-1)
+	if cnt, _ := decoded.CountT(k1, later); math.Abs(cnt-want) > eps {
+		t.Errorf("decoded decayed count should be %f, was %f", want, cnt)
+	}
+	decoded.UpdateT(k1, 1.0, later)
+	if cnt, _ := decoded.CountT(k1, later); math.Abs(cnt-want-1) > eps {
+		t.Errorf("decoded sketch should keep decaying updates, count %f", cnt)
+	}
+}
+
+// the plain functions are the built-in DECAY_NONE, so they decode into an empty sketch
+func TestCMSEncodingDirectPlain(t *testing.T) {
+	cms := streaming.MakeCMSDirect(100, 3, 1, streaming.Plain_update, streaming.Plain_read)
+	cms.Update([]byte("hello"), 3)
+	decoded := roundTrip(t, cms)
+	if cnt, _ := decoded.Count([]byte("hello")); cnt != 3 {
+		t.Errorf("count should be 3, was %f", cnt)
+	}
+	decoded.Update([]byte("hello"), 2)
+	if cnt, _ := decoded.Count([]byte("hello")); cnt != 5 {
+		t.Errorf("decoded sketch should keep counting, count %f", cnt)
+	}
+}
+
+// only custom functions keep a timestamp per cell
+func TestCMSEncodingDirectSize(t *testing.T) {
+	direct, _ := streaming.MakeCMSDirect(200, 7, 0, streaming.Plain_update, streaming.Plain_read).MarshalBinary()
+	plain, _ := streaming.MakeCMS(0.01, 0.01, 0).MarshalBinary()
+	custom, _ := streaming.MakeCMSDirect(200, 7, 0, doubleUpdate, streaming.Plain_read).MarshalBinary()
+	if len(direct) != len(plain) {
+		t.Errorf("plain direct sketch encoded to %d bytes, MakeCMS to %d", len(direct), len(plain))
+	}
+	if len(custom) <= len(plain) {
+		t.Errorf("custom sketch encoded to %d bytes, should keep timestamps", len(custom))
+	}
+}
+
+func doubleUpdate(elem *streaming.CMElement, update *streaming.CMUpdate) *streaming.CMElement {
+	elem.Weight += 2 * update.Weight
+	return elem
+}
+
+func TestCMSEncodingCustomFunctions(t *testing.T) {
+	cms := streaming.MakeCMSDirect(100, 4, 2, doubleUpdate, streaming.Plain_read)
+	cms.Update([]byte("hello"), 3)
+	data, _ := cms.MarshalBinary()
+	if err := (&streaming.CountMin{}).UnmarshalBinary(data); err != streaming.ErrCustomFunctions {
+		t.Errorf("decoding custom functions into an empty sketch should fail, got %v", err)
+	}
+	decoded := streaming.MakeCMSDirect(1, 1, 0, doubleUpdate, streaming.Plain_read)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if cnt, _ := decoded.Count([]byte("hello")); cnt != 6 {
+		t.Errorf("count should be 6, was %f", cnt)
+	}
+}
+
+func TestCMSEncodingCorrupt(t *testing.T) {
+	cms := streaming.MakeExpCMS(0.1, 0.1, 3, -0.1)
+	cms.Update([]byte("hello"), 1)
+	data, _ := cms.MarshalBinary()
+	decoded := &streaming.CountMin{}
+	for i := 0; i < len(data); i++ {
+		if err := decoded.UnmarshalBinary(data[:i]); err == nil {
+			t.Fatalf("decoding %d of %d bytes should fail", i, len(data))
+		}
+	}
+	bad := append([]byte{}, data...)
+	bad[0] = 99
+	if err := decoded.UnmarshalBinary(bad); err != streaming.ErrEncodingVersion {
+		t.Errorf("unknown version should fail, got %v", err)
+	}
+	// a huge width has to fail without allocating it
+	bad = append([]byte{}, data[:4]...)
+	bad = append(bad, 0xff, 0xff, 0xff, 0xff, 0x0f, 0xff, 0xff, 0xff, 0xff, 0x0f)
+	bad = append(bad, data[len(data)-40:]...)
+	if err := decoded.UnmarshalBinary(bad); err != streaming.ErrCorruptEncoding {
+		t.Errorf("huge dimensions should fail, got %v", err)
+	}
+}
+
+func TestWindowedCMSEncodingRoundTrip(t *testing.T) {
+	w, _ := streaming.MakeWindowedCMS(0.01, 0.01, 5, 4, 2)
+	k1, k2 := []byte("hello"), []byte("hello2")
+	w.Update(k1, 1.0)
+	w.Update(k2, 1.0)
+	w.Update(k2, 1.0)
+	data, err := w.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &streaming.WindowedCMS{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if cnt, _ := decoded.Count(k1); math.Abs(cnt-1.0) > eps {
+		t.Errorf("cnt should be 1.0, but was %f", cnt)
+	}
+	// the slice counter comes back, so both rotate k1 out at the same update
+	for _, s := range []*streaming.WindowedCMS{w, decoded} {
+		s.Update(k2, 1.0)
+		s.Update(k2, 1.0)
+		if cnt, _ := s.Count(k1); math.Abs(cnt-0.0) > eps {
+			t.Errorf("cnt should be 0.0, but was %f", cnt)
+		}
+	}
+}
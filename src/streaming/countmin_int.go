@@ -0,0 +1,141 @@
+package streaming
+
+import (
+	"math"
+)
+
+// count min sketches over integer counters, for streams of whole counts that don't decay.
+// they lay out cells like CountMin, without timestamps and in a half or a quarter of its
+// memory. weights are rounded to whole counts, and counters saturate at 0 and at their max.
+// with the same dimensions and seed they hash keys to the same cells as CountMin
+
+type CountMin32 struct {
+	counters   []uint32
+	numBuckets uint32
+	k          uint32
+	seed       int64
+	cells      []int
+}
+
+type CountMin64 struct {
+	counters   []uint64
+	numBuckets uint32
+	k          uint32
+	seed       int64
+	cells      []int
+}
+
+func MakeCMS32Direct(size uint32, numHash uint32, seed int64) *CountMin32 {
+	return &CountMin32{make([]uint32, int(size)*int(numHash)), size, numHash, seed, make([]int, numHash)}
+}
+
+func MakeCMS32(eps float64, p_error float64, seed int64) *CountMin32 {
+	size, numHashes := estimate(eps, p_error)
+	return MakeCMS32Direct(size, numHashes, seed)
+}
+
+func MakeCMS64Direct(size uint32, numHash uint32, seed int64) *CountMin64 {
+	return &CountMin64{make([]uint64, int(size)*int(numHash)), size, numHash, seed, make([]int, numHash)}
+}
+
+func MakeCMS64(eps float64, p_error float64, seed int64) *CountMin64 {
+	size, numHashes := estimate(eps, p_error)
+	return MakeCMS64Direct(size, numHashes, seed)
+}
+
+// weight rounded to a whole count
+func wholeCount(weight float64) int64 {
+	w := math.Round(weight)
+	if w >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	if w <= math.MinInt64 {
+		return math.MinInt64
+	}
+	return int64(w)
+}
+
+func (cms *CountMin32) Seed() int64 {
+	return cms.seed
+}
+
+func (cms *CountMin32) Update(data []byte, weight float64) {
+	n := wholeCount(weight)
+	fillCells(cms.cells, cms.seed, cms.numBuckets, data)
+	for _, cell := range cms.cells {
+		v := int64(cms.counters[cell]) + n
+		if n > math.MaxUint32 || v > math.MaxUint32 {
+			v = math.MaxUint32
+		} else if v < 0 {
+			v = 0
+		}
+		cms.counters[cell] = uint32(v)
+	}
+}
+
+func (cms *CountMin32) Count(data []byte) (float64, error) {
+	if cms.k == 0 {
+		return 0, ErrElementNotFound
+	}
+	fillCells(cms.cells, cms.seed, cms.numBuckets, data)
+	min := uint32(math.MaxUint32)
+	for _, cell := range cms.cells {
+		if cms.counters[cell] < min {
+			min = cms.counters[cell]
+		}
+	}
+	return float64(min), nil
+}
+
+func (cms *CountMin32) Reset() {
+	for i := range cms.counters {
+		cms.counters[i] = 0
+	}
+}
+
+func (cms *CountMin64) Seed() int64 {
+	return cms.seed
+}
+
+func (cms *CountMin64) Update(data []byte, weight float64) {
+	n := wholeCount(weight)
+	// |n|, written so that it holds for MinInt64 too
+	abs := uint64(n)
+	if n < 0 {
+		abs = uint64(-(n + 1)) + 1
+	}
+	fillCells(cms.cells, cms.seed, cms.numBuckets, data)
+	for _, cell := range cms.cells {
+		c := cms.counters[cell]
+		switch {
+		case n >= 0 && c > math.MaxUint64-abs:
+			cms.counters[cell] = math.MaxUint64
+		case n >= 0:
+			cms.counters[cell] = c + abs
+		case c < abs:
+			cms.counters[cell] = 0
+		default:
+			cms.counters[cell] = c - abs
+		}
+	}
+}
+
+func (cms *CountMin64) Count(data []byte) (float64, error) {
+	if cms.k == 0 {
+		return 0, ErrElementNotFound
+	}
+	fillCells(cms.cells, cms.seed, cms.numBuckets, data)
+	min := uint64(math.MaxUint64)
+	for _, cell := range cms.cells {
+		if cms.counters[cell] < min {
+			min = cms.counters[cell]
+		}
+	}
+	return float64(min), nil
+}
+
+func (cms *CountMin64) Reset() {
+	for i := range cms.counters {
+		cms.counters[i] = 0
+	}
+}
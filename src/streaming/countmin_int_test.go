@@ -0,0 +1,111 @@
+package streaming_test
+
+import (
+	"math"
+	"streaming"
+	"testing"
+)
+
+func TestIntCMSSanity(t *testing.T) {
+	for _, cms := range []streaming.CMSketch{streaming.MakeCMS32(0.01, 0.01, 2), streaming.MakeCMS64(0.01, 0.01, 2)} {
+		k1 := []byte("hello")
+		if cnt, _ := cms.Count(k1); cnt != 0 {
+			t.Error("shouldn't be anything yet")
+		}
+		for i := 0; i < 10; i++ {
+			cms.Update(k1, 1.0)
+		}
+		cms.Update(k1, 2.4)
+		if cnt, _ := cms.Count(k1); cnt != 12 {
+			t.Errorf("should have 12, had %f", cnt)
+		}
+		cms.Reset()
+		if cnt, _ := cms.Count(k1); cnt != 0 {
+			t.Errorf("reset should have made count be 0, instead was %f", cnt)
+		}
+	}
+}
+
+// same dimensions and seed put keys in the same cells, so whole counts match exactly
+func TestIntCMSMatchesFloat(t *testing.T) {
+	items, actual := zipfStream(8, 50000)
+	sketches := []streaming.CMSketch{
+		streaming.MakeCMS(0.01, 0.01, 9),
+		streaming.MakeCMS32(0.01, 0.01, 9),
+		streaming.MakeCMS64(0.01, 0.01, 9),
+	}
+	for _, x := range items {
+		for _, cms := range sketches {
+			cms.Update(intToBuf(int32(x)), 1)
+		}
+	}
+	for x := range actual {
+		want, _ := sketches[0].Count(intToBuf(int32(x)))
+		for _, cms := range sketches[1:] {
+			if got, _ := cms.Count(intToBuf(int32(x))); got != want {
+				t.Fatalf("key %d: integer sketch counted %f, float sketch %f", x, got, want)
+			}
+		}
+	}
+}
+
+func TestIntCMSSaturates(t *testing.T) {
+	k1 := []byte("hello")
+	cms32 := streaming.MakeCMS32Direct(10, 2, 0)
+	cms32.Update(k1, 5)
+	cms32.Update(k1, -10)
+	if cnt, _ := cms32.Count(k1); cnt != 0 {
+		t.Errorf("count should stop at 0, was %f", cnt)
+	}
+	cms32.Update(k1, 1e12)
+	if cnt, _ := cms32.Count(k1); cnt != math.MaxUint32 {
+		t.Errorf("count should stop at max uint32, was %f", cnt)
+	}
+	cms64 := streaming.MakeCMS64Direct(10, 2, 0)
+	cms64.Update(k1, 5)
+	cms64.Update(k1, math.Inf(-1))
+	if cnt, _ := cms64.Count(k1); cnt != 0 {
+		t.Errorf("count should stop at 0, was %f", cnt)
+	}
+	cms64.Update(k1, 1e30)
+	cms64.Update(k1, 1e30)
+	if cnt, _ := cms64.Count(k1); cnt != math.MaxUint64 {
+		t.Errorf("count should stop at max uint64, was %f", cnt)
+	}
+}
+
+func TestCMSNoAllocs(t *testing.T) {
+	k1 := []byte("hello")
+	sketches := map[string]streaming.CMSketch{
+		"float":  streaming.MakeCMS(0.001, 0.01, 0),
+		"direct": streaming.MakeCMSDirect(2000, 7, 0, streaming.Plain_update, streaming.Plain_read),
+		"exp":    streaming.MakeExpCMS(0.001, 0.01, 0, -0.1),
+		"cmm":    streaming.MakeCMSWithModes(0.001, 0.01, 0, streaming.UPDATE_CONSERVATIVE, streaming.READ_COUNT_MEAN_MIN),
+		"uint32": streaming.MakeCMS32(0.001, 0.01, 0),
+		"uint64": streaming.MakeCMS64(0.001, 0.01, 0),
+	}
+	for name, cms := range sketches {
+		if allocs := testing.AllocsPerRun(100, func() { cms.Update(k1, 1) }); allocs != 0 {
+			t.Errorf("%s: update allocated %f times", name, allocs)
+		}
+		if allocs := testing.AllocsPerRun(100, func() { cms.Count(k1) }); allocs != 0 {
+			t.Errorf("%s: count allocated %f times", name, allocs)
+		}
+	}
+}
+
+func BenchmarkCMS32Update(b *testing.B) {
+	benchmarkUpdate(b, streaming.MakeCMS32(0.0001, 0.01, 0))
+}
+
+func BenchmarkCMS32Count(b *testing.B) {
+	benchmarkCount(b, streaming.MakeCMS32(0.0001, 0.01, 0))
+}
+
+func BenchmarkCMS64Update(b *testing.B) {
+	benchmarkUpdate(b, streaming.MakeCMS64(0.0001, 0.01, 0))
+}
+
+func BenchmarkCMS64Count(b *testing.B) {
+	benchmarkCount(b, streaming.MakeCMS64(0.0001, 0.01, 0))
+}
@@ -112,6 +112,218 @@ func TestCMSAccuracy(t *testing.T) {
 	}
 }
 
+// zipfian keys with exponent 1.1 over a universe of 100k, and their true counts
+func zipfStream(seed int64, n int) ([]uint64, map[uint64]float64) {
+	zipf := rand.NewZipf(rand.New(rand.NewSource(seed)), 1.1, 1, 100000)
+	items := make([]uint64, n)
+	actual := make(map[uint64]float64)
+	for i := range items {
+		items[i] = zipf.Uint64()
+		actual[items[i]]++
+	}
+	return items, actual
+}
+
+// mean absolute error of cms over the keys seen, after a zipfian stream
+func zipfError(cms streaming.CMSketch) float64 {
+	items, actual := zipfStream(1234, 200000)
+	for _, x := range items {
+		cms.Update(intToBuf(int32(x)), 1)
+	}
+	sum := 0.0
+	for x, cnt := range actual {
+		est, _ := cms.Count(intToBuf(int32(x)))
+		sum += math.Abs(est - cnt)
+	}
+	return sum / float64(len(actual))
+}
+
+func TestCMSZipfAccuracy(t *testing.T) {
+	// small enough that collisions matter
+	plain := zipfError(streaming.MakeCMSWithModes(0.002, 0.01, 0, streaming.UPDATE_ALL, streaming.READ_MIN))
+	conservative := zipfError(streaming.MakeCMSWithModes(0.002, 0.01, 0, streaming.UPDATE_CONSERVATIVE, streaming.READ_MIN))
+	countMeanMin := zipfError(streaming.MakeCMSWithModes(0.002, 0.01, 0, streaming.UPDATE_ALL, streaming.READ_COUNT_MEAN_MIN))
+	t.Logf("mean abs error: plain %.2f, conservative %.2f, count-mean-min %.2f", plain, conservative, countMeanMin)
+	if conservative > 0.75*plain {
+		t.Errorf("conservative update error %.2f not well below plain %.2f", conservative, plain)
+	}
+	if countMeanMin > plain/2 {
+		t.Errorf("count-mean-min error %.2f not well below plain %.2f", countMeanMin, plain)
+	}
+}
+
+func TestCMSConservativeNeverUnderestimates(t *testing.T) {
+	items, actual := zipfStream(99, 50000)
+	cms := streaming.MakeCMSWithModes(0.01, 0.01, 0, streaming.UPDATE_CONSERVATIVE, streaming.READ_MIN)
+	for _, x := range items {
+		cms.Update(intToBuf(int32(x)), 1)
+	}
+	for x, cnt := range actual {
+		if est, _ := cms.Count(intToBuf(int32(x))); est < cnt {
+			t.Fatalf("key %d: estimate %.0f below true count %.0f", x, est, cnt)
+		}
+	}
+}
+
+func TestExpCMSConservative(t *testing.T) {
+	now := time.Now()
+	k1, k2 := []byte("hello"), []byte("world")
+	cms := streaming.MakeExpCMSWithModes(0.01, 0.01, 0, -0.1, streaming.UPDATE_CONSERVATIVE, streaming.READ_MIN)
+	cms.UpdateT(k1, 1.0, now)
+	cms.UpdateT(k1, 1.0, now.Add(10*time.Second))
+	cms.UpdateT(k2, 3.0, now.Add(10*time.Second))
+	want := math.Exp(-1) + 1
+	if cnt, _ := cms.CountT(k1, now.Add(10*time.Second)); math.Abs(cnt-want) > eps {
+		t.Errorf("decayed count should be %f, was %f", want, cnt)
+	}
+	if cnt, _ := cms.CountT(k1, now.Add(20*time.Second)); math.Abs(cnt-want*math.Exp(-1)) > eps {
+		t.Errorf("decayed count should be %f, was %f", want*math.Exp(-1), cnt)
+	}
+}
+
+func TestCMSMerge(t *testing.T) {
+	items, actual := zipfStream(42, 100000)
+	whole := streaming.MakeCMS(0.001, 0.01, 17)
+	workers := []*streaming.CountMin{
+		streaming.MakeCMS(0.001, 0.01, 17),
+		streaming.MakeCMS(0.001, 0.01, 17),
+		streaming.MakeCMS(0.001, 0.01, 17),
+	}
+	for i, x := range items {
+		whole.Update(intToBuf(int32(x)), 1)
+		workers[i%len(workers)].Update(intToBuf(int32(x)), 1)
+	}
+	merged := streaming.MakeCMS(0.001, 0.01, 17)
+	for _, w := range workers {
+		if err := merged.Merge(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for x := range actual {
+		want, _ := whole.Count(intToBuf(int32(x)))
+		if got, _ := merged.Count(intToBuf(int32(x))); got != want {
+			t.Fatalf("key %d: merged count %.0f, single sketch %.0f", x, got, want)
+		}
+	}
+}
+
+func TestCMSMergeIncompatible(t *testing.T) {
+	cms := streaming.MakeCMS(0.001, 0.01, 1)
+	if err := cms.Merge(streaming.MakeCMS(0.001, 0.01, 2)); err != streaming.ErrIncompatibleSketches {
+		t.Errorf("merging different seeds should fail, got %v", err)
+	}
+	if err := cms.Merge(streaming.MakeCMS(0.01, 0.01, 1)); err != streaming.ErrIncompatibleSketches {
+		t.Errorf("merging different widths should fail, got %v", err)
+	}
+}
+
+func TestExpCMSMerge(t *testing.T) {
+	now := time.Now()
+	k1 := []byte("hello")
+	a := streaming.MakeExpCMS(0.01, 0.01, 3, -0.1)
+	b := streaming.MakeExpCMS(0.01, 0.01, 3, -0.1)
+	a.UpdateT(k1, 1.0, now)
+	b.UpdateT(k1, 2.0, now.Add(10*time.Second))
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	want := math.Exp(-1) + 2
+	if cnt, _ := a.CountT(k1, now.Add(10*time.Second)); math.Abs(cnt-want) > eps {
+		t.Errorf("merged decayed count should be %f, was %f", want, cnt)
+	}
+}
+
+// with one row, the keys that collide with a given key depend on the seed
+func TestCMSSeedSelectsHash(t *testing.T) {
+	colliding := func(seed int64) map[int32]bool {
+		cms := streaming.MakeCMSDirect(100, 1, seed, streaming.Plain_update, streaming.Plain_read)
+		cms.Update([]byte("hello"), 1)
+		keys := make(map[int32]bool)
+		for i := int32(0); i < 1000; i++ {
+			if cnt, _ := cms.Count(intToBuf(i)); cnt > 0 {
+				keys[i] = true
+			}
+		}
+		return keys
+	}
+	a, b := colliding(1), colliding(2)
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	if len(a) == 0 || shared == len(a) {
+		t.Errorf("seeds 1 and 2 share %d of %d collisions", shared, len(a))
+	}
+}
+
+func TestCMSCountWithBounds(t *testing.T) {
+	items, actual := zipfStream(12, 100000)
+	for _, readMode := range []streaming.ReadMode{streaming.READ_MIN, streaming.READ_COUNT_MEAN_MIN} {
+		var cms streaming.BoundedCMSketch = streaming.MakeCMSWithModes(0.005, 0.01, 0, streaming.UPDATE_ALL, readMode)
+		for _, x := range items {
+			cms.Update(intToBuf(int32(x)), 1)
+		}
+		if cms.TotalWeight() != float64(len(items)) {
+			t.Errorf("total weight should be %d, was %f", len(items), cms.TotalWeight())
+		}
+		if cms.Epsilon() != 0.005 || cms.Delta() != 1.0/128 {
+			t.Errorf("eps and delta should be 0.005 and 1/128, were %f and %f", cms.Epsilon(), cms.Delta())
+		}
+		misses := 0
+		for x, cnt := range actual {
+			est, lower, upper := cms.CountWithBounds(intToBuf(int32(x)))
+			if est < lower || est > upper || upper-lower > cms.Epsilon()*cms.TotalWeight() {
+				t.Fatalf("key %d: estimate %f outside [%f, %f]", x, est, lower, upper)
+			}
+			if cnt < lower || cnt > upper {
+				misses++
+			}
+		}
+		if float64(misses) > cms.Delta()*float64(len(actual)) {
+			t.Errorf("%d of %d true counts outside their bounds", misses, len(actual))
+		}
+	}
+}
+
+// negative weights add to N too, or a key's cell could sit further above its count than
+// eps N allows
+func TestCMSBoundsNegativeWeights(t *testing.T) {
+	cms := streaming.MakeCMSDirect(2, 1, 0, streaming.Plain_update, streaming.Plain_read)
+	a, b, c := []byte("a"), []byte(nil), []byte(nil)
+	cms.Update(a, 1)
+	for i := int32(0); b == nil || c == nil; i++ {
+		if cnt, _ := cms.Count(intToBuf(i)); cnt == 1 && b == nil {
+			b = intToBuf(i)
+		} else if cnt == 0 && c == nil {
+			c = intToBuf(i)
+		}
+	}
+	cms.Reset()
+	// a and b share a cell, c has the other
+	cms.Update(a, 10)
+	cms.Update(b, 10)
+	cms.Update(c, -20)
+	if cms.TotalWeight() != 40 {
+		t.Errorf("total weight should be 40, was %f", cms.TotalWeight())
+	}
+	if _, lower, upper := cms.CountWithBounds(a); lower > 10 || upper < 10 {
+		t.Errorf("count of 10 outside its bounds [%f, %f]", lower, upper)
+	}
+}
+
+func TestExpCMSTotalWeight(t *testing.T) {
+	now := time.Now()
+	cms := streaming.MakeExpCMS(0.01, 0.01, 0, -0.1)
+	cms.UpdateT([]byte("hello"), 2, now)
+	cms.UpdateT([]byte("world"), 1, now.Add(10*time.Second))
+	want := 2*math.Exp(-2) + math.Exp(-1)
+	if total := cms.TotalWeightT(now.Add(20 * time.Second)); math.Abs(total-want) > eps {
+		t.Errorf("decayed total should be %f, was %f", want, total)
+	}
+}
+
 func intToBuf(data int32) []byte {
 	buf := make([]byte, 8)
 	if wrote := binary.PutVarint(buf, int64(data)); wrote < 1 {
@@ -119,3 +331,55 @@ func intToBuf(data int32) []byte {
 	}
 	return buf
 }
+
+func benchKeys() [][]byte {
+	keys := make([][]byte, 1<<16)
+	for i := range keys {
+		keys[i] = intToBuf(int32(i))
+	}
+	return keys
+}
+
+func benchmarkUpdate(b *testing.B, cms streaming.CMSketch) {
+	keys := benchKeys()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cms.Update(keys[i&(len(keys)-1)], 1)
+	}
+}
+
+func benchmarkCount(b *testing.B, cms streaming.CMSketch) {
+	keys := benchKeys()
+	for _, k := range keys {
+		cms.Update(k, 1)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cms.Count(keys[i&(len(keys)-1)])
+	}
+}
+
+func BenchmarkCMSUpdate(b *testing.B) {
+	benchmarkUpdate(b, streaming.MakeCMS(0.0001, 0.01, 0))
+}
+
+func BenchmarkCMSCount(b *testing.B) {
+	benchmarkCount(b, streaming.MakeCMS(0.0001, 0.01, 0))
+}
+
+func BenchmarkExpCMSUpdate(b *testing.B) {
+	benchmarkUpdate(b, streaming.MakeExpCMS(0.0001, 0.01, 0, -0.1))
+}
+
+func BenchmarkExpCMSCount(b *testing.B) {
+	benchmarkCount(b, streaming.MakeExpCMS(0.0001, 0.01, 0, -0.1))
+}
+
+func BenchmarkMakeCMS(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		streaming.MakeCMS(0.0001, 0.01, 0)
+	}
+}
@@ -0,0 +1,129 @@
+package streaming
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+var ErrInvalidBits = errors.New("cms: universe has to be 1 to 64 bits wide")
+var ErrOutOfUniverse = errors.New("cms: value outside the universe of the sketch")
+var ErrInvalidQuantile = errors.New("cms: quantile has to be in [0, 1] and the sketch non-empty")
+
+// count min sketches over the dyadic ranges of the integers [0, 2^bits): level l counts x >> l,
+// so every range splits into at most 2 ranges per level, and every rank into at most one per
+// level (Cormode, Muthukrishnan). each level overestimates by at most eps N with probability
+// 1 - p_error, so a range count is off by at most 2 bits eps N and a quantile's rank by at
+// most bits eps N
+type DyadicCMS struct {
+	levels []*CountMin
+	bits   uint
+	eps    float64
+	total  float64
+	key    [8]byte
+}
+
+// levels are seeded seed, seed+1, ..., so they hash independently
+func MakeDyadicCMS(bits uint, eps float64, p_error float64, seed int64) (*DyadicCMS, error) {
+	if bits < 1 || bits > 64 {
+		return nil, ErrInvalidBits
+	}
+	levels := make([]*CountMin, bits)
+	for l := range levels {
+		levels[l] = MakeCMS(eps, p_error, seed+int64(l))
+	}
+	return &DyadicCMS{levels: levels, bits: bits, eps: eps}, nil
+}
+
+func (d *DyadicCMS) max() uint64 {
+	return ^uint64(0) >> (64 - d.bits)
+}
+
+func (d *DyadicCMS) UpdateInt(x uint64, weight float64) error {
+	if x > d.max() {
+		return ErrOutOfUniverse
+	}
+	for l, level := range d.levels {
+		binary.LittleEndian.PutUint64(d.key[:], x>>uint(l))
+		level.UpdateT(d.key[:], weight, time.Time{})
+	}
+	d.total += weight
+	return nil
+}
+
+// the estimated weight of the dyadic range prefix of level l
+func (d *DyadicCMS) count(l uint, prefix uint64) float64 {
+	binary.LittleEndian.PutUint64(d.key[:], prefix)
+	est, _ := d.levels[l].CountT(d.key[:], time.Time{})
+	return est
+}
+
+// the weight of all x in [lo, hi], within RangeError with probability 1 - bits p_error
+func (d *DyadicCMS) RangeCount(lo uint64, hi uint64) (float64, error) {
+	if lo > hi || hi > d.max() {
+		return 0, ErrOutOfUniverse
+	}
+	sum := 0.0
+	// climbing from the leaves, an odd lo and an even hi are the only nodes of their level that
+	// are inside the range while their parent is not
+	for l := uint(0); l < d.bits; l++ {
+		if lo&1 == 1 {
+			sum += d.count(l, lo)
+			if lo == hi {
+				return sum, nil
+			}
+			lo++
+		}
+		if hi&1 == 0 {
+			sum += d.count(l, hi)
+			if lo == hi {
+				return sum, nil
+			}
+			hi--
+		}
+		lo >>= 1
+		hi >>= 1
+	}
+	// the whole universe
+	return sum + d.total, nil
+}
+
+// the smallest x with an estimated weight of at least q N in [0, x]. the rank of x is off by at
+// most QuantileError
+func (d *DyadicCMS) Quantile(q float64) (uint64, error) {
+	if q < 0 || q > 1 || d.total <= 0 {
+		return 0, ErrInvalidQuantile
+	}
+	target := q * d.total
+	prefix, below := uint64(0), 0.0
+	for l := int(d.bits) - 1; l >= 0; l-- {
+		left := prefix << 1
+		if c := d.count(uint(l), left); below+c >= target {
+			prefix = left
+		} else {
+			below += c
+			prefix = left + 1
+		}
+	}
+	return prefix, nil
+}
+
+// N, the weight of the stream
+func (d *DyadicCMS) TotalWeight() float64 {
+	return d.total
+}
+
+func (d *DyadicCMS) RangeError() float64 {
+	return 2 * float64(d.bits) * d.eps * d.total
+}
+
+func (d *DyadicCMS) QuantileError() float64 {
+	return float64(d.bits) * d.eps * d.total
+}
+
+func (d *DyadicCMS) Reset() {
+	for _, level := range d.levels {
+		level.Reset()
+	}
+	d.total = 0
+}
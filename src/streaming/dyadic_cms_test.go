@@ -0,0 +1,98 @@
+package streaming_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"streaming"
+	"testing"
+)
+
+// latencies in ms, mostly around 100 with a long tail
+func latencies(n int) []uint64 {
+	r := rand.New(rand.NewSource(77))
+	values := make([]uint64, n)
+	for i := range values {
+		values[i] = uint64(math.Min(4095, math.Exp(r.NormFloat64()*0.6+math.Log(100))))
+	}
+	return values
+}
+
+func TestDyadicRangeCount(t *testing.T) {
+	values := latencies(100000)
+	d, err := streaming.MakeDyadicCMS(12, 0.001, 0.01, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range values {
+		d.UpdateInt(v, 1)
+	}
+	for _, r := range [][2]uint64{{100, 250}, {0, 4095}, {0, 0}, {4095, 4095}, {37, 38}, {1, 4094}, {99, 100}} {
+		exact := 0.0
+		for _, v := range values {
+			if v >= r[0] && v <= r[1] {
+				exact++
+			}
+		}
+		est, err := d.RangeCount(r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if est < exact || est-exact > d.RangeError() {
+			t.Errorf("range [%d, %d]: estimate %.0f, exact %.0f, bound %.0f", r[0], r[1], est, exact, d.RangeError())
+		}
+	}
+	if _, err := d.RangeCount(10, 4096); err != streaming.ErrOutOfUniverse {
+		t.Errorf("range past the universe should fail, got %v", err)
+	}
+	if err := d.UpdateInt(4096, 1); err != streaming.ErrOutOfUniverse {
+		t.Errorf("value past the universe should fail, got %v", err)
+	}
+}
+
+func TestDyadicQuantile(t *testing.T) {
+	values := latencies(100000)
+	d, _ := streaming.MakeDyadicCMS(12, 0.001, 0.01, 3)
+	for _, v := range values {
+		d.UpdateInt(v, 1)
+	}
+	sorted := append([]uint64{}, values...)
+	sort.Sort(uint64s(sorted))
+	for _, q := range []float64{0, 0.01, 0.25, 0.5, 0.9, 0.99, 1} {
+		x, err := d.Quantile(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rank := float64(sort.Search(len(sorted), func(i int) bool { return sorted[i] > x }))
+		if math.Abs(rank-q*float64(len(values))) > d.QuantileError()+1 {
+			t.Errorf("quantile %.2f: %d has rank %.0f, bound %.0f", q, x, rank, d.QuantileError())
+		}
+	}
+	if _, err := d.Quantile(1.5); err != streaming.ErrInvalidQuantile {
+		t.Errorf("quantile past 1 should fail, got %v", err)
+	}
+}
+
+func TestDyadic64Bits(t *testing.T) {
+	d, _ := streaming.MakeDyadicCMS(64, 0.01, 0.01, 0)
+	d.UpdateInt(math.MaxUint64, 2)
+	d.UpdateInt(0, 1)
+	if cnt, _ := d.RangeCount(0, math.MaxUint64); cnt != 3 {
+		t.Errorf("whole universe should count 3, was %f", cnt)
+	}
+	if cnt, _ := d.RangeCount(1, math.MaxUint64); cnt != 2 {
+		t.Errorf("all but 0 should count 2, was %f", cnt)
+	}
+	if x, _ := d.Quantile(1); x != math.MaxUint64 {
+		t.Errorf("max should be max uint64, was %d", x)
+	}
+	if _, err := streaming.MakeDyadicCMS(65, 0.01, 0.01, 0); err != streaming.ErrInvalidBits {
+		t.Errorf("65 bits should fail, got %v", err)
+	}
+}
+
+type uint64s []uint64
+
+func (u uint64s) Len() int           { return len(u) }
+func (u uint64s) Less(i, j int) bool { return u[i] < u[j] }
+func (u uint64s) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
@@ -0,0 +1,237 @@
+package streaming
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrInvalidWindow = errors.New("cms: window has to be positive and at most the max window")
+
+// exponential histogram, from "Maintaining Stream Statistics over Sliding Windows" (Datar,
+// Gionis, Indyk, Motwani)
+// arrivals are grouped into buckets of 2^j, and a bucket keeps only the time of its newest
+// arrival. levels[j] holds the times of the buckets of 2^j, oldest first. once a level has
+// more than limit buckets its two oldest become one on the next level, so only the oldest
+// bucket in a window can straddle its start, and it is small next to the newer ones
+type expHistogram struct {
+	levels [][]int64
+}
+
+// inserts t into a level kept in time order, usually at the end
+func insertSorted(level []int64, t int64) []int64 {
+	i := len(level)
+	for i > 0 && level[i-1] > t {
+		i--
+	}
+	level = append(level, 0)
+	copy(level[i+1:], level[i:])
+	level[i] = t
+	return level
+}
+
+func (eh *expHistogram) cascade(limit int) {
+	for j := 0; j < len(eh.levels); j++ {
+		for len(eh.levels[j]) > limit {
+			merged := eh.levels[j][1]
+			eh.levels[j] = eh.levels[j][2:]
+			if j+1 == len(eh.levels) {
+				eh.levels = append(eh.levels, nil)
+			}
+			eh.levels[j+1] = insertSorted(eh.levels[j+1], merged)
+		}
+	}
+}
+
+// adds n arrivals at t. the first limit go in one at a time, so small weights stay in buckets
+// of 1 that count takes at face value, and the rest as a bucket of 2^j for every bit j set
+func (eh *expHistogram) add(t int64, n int64, limit int) {
+	if len(eh.levels) == 0 {
+		eh.levels = make([][]int64, 1)
+	}
+	for i := 0; n > 0 && i < limit; i, n = i+1, n-1 {
+		eh.levels[0] = insertSorted(eh.levels[0], t)
+		eh.cascade(limit)
+	}
+	for j := 0; n>>uint(j) != 0; j++ {
+		if n>>uint(j)&1 == 0 {
+			continue
+		}
+		for len(eh.levels) <= j {
+			eh.levels = append(eh.levels, nil)
+		}
+		eh.levels[j] = insertSorted(eh.levels[j], t)
+	}
+	eh.cascade(limit)
+}
+
+// drops the buckets whose newest arrival is at or before cutoff
+func (eh *expHistogram) expire(cutoff int64) {
+	for j, level := range eh.levels {
+		i := 0
+		for i < len(level) && level[i] <= cutoff {
+			i++
+		}
+		eh.levels[j] = level[i:]
+	}
+}
+
+// the arrivals after from and at or before to. the oldest bucket in range is counted as half
+// of it, which is off by less than its size / 2
+func (eh *expHistogram) count(from int64, to int64) float64 {
+	total, oldest, oldestSize := 0.0, int64(math.MaxInt64), 0.0
+	for j, level := range eh.levels {
+		size := float64(uint64(1) << uint(j))
+		for _, t := range level {
+			if t > from && t <= to {
+				total += size
+				if t < oldest {
+					oldest, oldestSize = t, size
+				}
+			}
+		}
+	}
+	if oldestSize > 1 {
+		total -= (oldestSize - 1) / 2
+	}
+	return total
+}
+
+func (eh *expHistogram) merge(other *expHistogram, limit int) {
+	for len(eh.levels) < len(other.levels) {
+		eh.levels = append(eh.levels, nil)
+	}
+	for j, level := range other.levels {
+		for _, t := range level {
+			eh.levels[j] = insertSorted(eh.levels[j], t)
+		}
+	}
+	eh.cascade(limit)
+}
+
+// ECM-sketch, from "Sketching distributed sliding-window data streams" (Papapetrou,
+// Garofalakis, Deligiannakis)
+// a count min sketch whose cells are exponential histograms, so it counts any window up to
+// maxWindow. eps is split into eps_cm for the sketch and eps_sw for the histograms with
+// (1 + eps_cm)(1 + eps_sw) = 1 + eps, which keeps a count within eps times the weight of the
+// window with probability 1 - p_error. weights are whole counts, and an update costs
+// about the log of its weight
+type ECMSketch struct {
+	cells      []expHistogram // row after row, then the whole stream
+	numBuckets uint32
+	k          uint32
+	seed       int64
+	eps        float64
+	limit      int   // buckets per histogram level
+	maxWindow  int64 // nanos
+	clock      Clock
+	rows       []int
+}
+
+// clock tells the time of Update and Count, time.Now if it is nil
+func MakeECMSketch(eps float64, p_error float64, seed int64, maxWindow time.Duration, clock Clock) (*ECMSketch, error) {
+	if maxWindow <= 0 {
+		return nil, ErrInvalidWindow
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	split := math.Sqrt(1+eps) - 1
+	size, numHashes := estimate(split, p_error)
+	return &ECMSketch{
+		cells:      make([]expHistogram, int(size)*int(numHashes)+1),
+		numBuckets: size,
+		k:          numHashes,
+		seed:       seed,
+		eps:        eps,
+		limit:      int(math.Ceil(1/split))/2 + 2,
+		maxWindow:  int64(maxWindow),
+		clock:      clock,
+		rows:       make([]int, numHashes),
+	}, nil
+}
+
+func (ecm *ECMSketch) Seed() int64 {
+	return ecm.seed
+}
+
+func (ecm *ECMSketch) Epsilon() float64 {
+	return ecm.eps
+}
+
+func (ecm *ECMSketch) Update(data []byte, weight float64) {
+	ecm.UpdateT(data, weight, ecm.clock())
+}
+
+func (ecm *ECMSketch) UpdateT(data []byte, weight float64, updateTime time.Time) {
+	n := wholeCount(weight)
+	if n <= 0 {
+		return
+	}
+	t := updateTime.UnixNano()
+	fillCells(ecm.rows, ecm.seed, ecm.numBuckets, data)
+	for _, cell := range ecm.rows {
+		ecm.add(cell, t, n)
+	}
+	ecm.add(len(ecm.cells)-1, t, n)
+}
+
+func (ecm *ECMSketch) add(cell int, t int64, n int64) {
+	ecm.cells[cell].expire(t - ecm.maxWindow)
+	ecm.cells[cell].add(t, n, ecm.limit)
+}
+
+// the count over the max window
+func (ecm *ECMSketch) Count(data []byte) (float64, error) {
+	return ecm.CountWindowT(data, time.Duration(ecm.maxWindow), ecm.clock())
+}
+
+func (ecm *ECMSketch) CountWindow(data []byte, window time.Duration) (float64, error) {
+	return ecm.CountWindowT(data, window, ecm.clock())
+}
+
+// the count over (now - window, now]
+func (ecm *ECMSketch) CountWindowT(data []byte, window time.Duration, now time.Time) (float64, error) {
+	if window <= 0 || int64(window) > ecm.maxWindow {
+		return 0, ErrInvalidWindow
+	}
+	if ecm.k == 0 {
+		return 0, ErrElementNotFound
+	}
+	to := now.UnixNano()
+	fillCells(ecm.rows, ecm.seed, ecm.numBuckets, data)
+	min := MAX_FLOAT64
+	for _, cell := range ecm.rows {
+		if c := ecm.cells[cell].count(to-int64(window), to); c < min {
+			min = c
+		}
+	}
+	return min, nil
+}
+
+// the weight of the whole stream over (now - window, now], within eps_sw of it. counts are
+// within eps times this
+func (ecm *ECMSketch) TotalWeightT(window time.Duration, now time.Time) float64 {
+	to := now.UnixNano()
+	return ecm.cells[len(ecm.cells)-1].count(to-int64(window), to)
+}
+
+func (ecm *ECMSketch) Reset() {
+	for i := range ecm.cells {
+		ecm.cells[i] = expHistogram{}
+	}
+}
+
+// adds the sketch of another node's stream, cell by cell. both need the same dimensions,
+// seed, eps and max window. the histograms of the merge straddle a window start in two places,
+// so its error is up to twice eps
+func (ecm *ECMSketch) Merge(other *ECMSketch) error {
+	if ecm.numBuckets != other.numBuckets || ecm.k != other.k || ecm.seed != other.seed ||
+		ecm.eps != other.eps || ecm.maxWindow != other.maxWindow {
+		return ErrIncompatibleSketches
+	}
+	for i := range ecm.cells {
+		ecm.cells[i].merge(&other.cells[i], ecm.limit)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package streaming_test
+
+import (
+	"math"
+	"math/rand"
+	"streaming"
+	"testing"
+	"time"
+)
+
+type timedItem struct {
+	key uint64
+	at  time.Time
+}
+
+// zipfian keys arriving every 10ms on average, at random gaps
+func timedZipfStream(seed int64, n int, start time.Time) []timedItem {
+	items, _ := zipfStream(seed, n)
+	r := rand.New(rand.NewSource(seed))
+	stream := make([]timedItem, n)
+	at := start
+	for i, x := range items {
+		at = at.Add(time.Duration(r.Int63n(int64(20 * time.Millisecond))))
+		stream[i] = timedItem{x, at}
+	}
+	return stream
+}
+
+func exactWindow(stream []timedItem, key uint64, from time.Time, to time.Time) (float64, float64) {
+	cnt, total := 0.0, 0.0
+	for _, it := range stream {
+		if it.at.After(from) && !it.at.After(to) {
+			total++
+			if it.key == key {
+				cnt++
+			}
+		}
+	}
+	return cnt, total
+}
+
+func TestECMSketchWindows(t *testing.T) {
+	start := time.Unix(1000000, 0)
+	stream := timedZipfStream(6, 30000, start)
+	ecm, err := streaming.MakeECMSketch(0.05, 0.01, 1, 5*time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range stream {
+		ecm.UpdateT(intToBuf(int32(it.key)), 1, it.at)
+	}
+	now := stream[len(stream)-1].at
+	for _, window := range []time.Duration{time.Second, 10 * time.Second, time.Minute, 5 * time.Minute} {
+		_, total := exactWindow(stream, 0, now.Add(-window), now)
+		if got := ecm.TotalWeightT(window, now); got < total*0.95 || got > total*1.05 {
+			t.Errorf("window %v: total weight %f, exact %f", window, got, total)
+		}
+		for key := uint64(1); key <= 20; key++ {
+			cnt, _ := exactWindow(stream, key, now.Add(-window), now)
+			est, err := ecm.CountWindowT(intToBuf(int32(key)), window, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if est < cnt*0.95 || est > cnt+0.05*total {
+				t.Errorf("window %v key %d: estimate %f, exact %f, eps N %f", window, key, est, cnt, 0.05*total)
+			}
+		}
+	}
+	if _, err := ecm.CountWindowT(intToBuf(1), 6*time.Minute, now); err != streaming.ErrInvalidWindow {
+		t.Errorf("window past the max should fail, got %v", err)
+	}
+}
+
+func TestECMSketchExpires(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	clock := func() time.Time { return now }
+	ecm, _ := streaming.MakeECMSketch(0.1, 0.01, 1, time.Minute, clock)
+	k1 := []byte("hello")
+	ecm.Update(k1, 3)
+	now = now.Add(30 * time.Second)
+	ecm.Update(k1, 2)
+	if cnt, _ := ecm.Count(k1); cnt != 5 {
+		t.Errorf("count should be 5, was %f", cnt)
+	}
+	if cnt, _ := ecm.CountWindow(k1, 10*time.Second); cnt != 2 {
+		t.Errorf("count over the last 10s should be 2, was %f", cnt)
+	}
+	now = now.Add(35 * time.Second)
+	if cnt, _ := ecm.Count(k1); cnt != 2 {
+		t.Errorf("count should be 2 once the first update left the window, was %f", cnt)
+	}
+	now = now.Add(30 * time.Second)
+	if cnt, _ := ecm.Count(k1); cnt != 0 {
+		t.Errorf("count should be 0 once both updates left the window, was %f", cnt)
+	}
+}
+
+// a weight goes in as one bucket per set bit, not one arrival at a time
+func TestECMSketchLargeWeight(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	clock := func() time.Time { return now }
+	ecm, _ := streaming.MakeECMSketch(0.1, 0.01, 1, time.Minute, clock)
+	k1 := []byte("hello")
+	ecm.Update(k1, 1e9)
+	now = now.Add(time.Second)
+	ecm.Update(k1, 1e9+7)
+	if cnt, _ := ecm.Count(k1); math.Abs(cnt-2e9-7) > 0.1*(2e9+7) {
+		t.Errorf("count should be about 2e9, was %f", cnt)
+	}
+	if cnt, _ := ecm.CountWindow(k1, 500*time.Millisecond); math.Abs(cnt-1e9-7) > 0.1*(1e9+7) {
+		t.Errorf("count over the last 500ms should be about 1e9, was %f", cnt)
+	}
+}
+
+func TestECMSketchMerge(t *testing.T) {
+	start := time.Unix(1000000, 0)
+	stream := timedZipfStream(7, 20000, start)
+	nodes := []*streaming.ECMSketch{}
+	for i := 0; i < 3; i++ {
+		ecm, _ := streaming.MakeECMSketch(0.05, 0.01, 1, 5*time.Minute, nil)
+		nodes = append(nodes, ecm)
+	}
+	for i, it := range stream {
+		nodes[i%3].UpdateT(intToBuf(int32(it.key)), 1, it.at)
+	}
+	merged, _ := streaming.MakeECMSketch(0.05, 0.01, 1, 5*time.Minute, nil)
+	for _, node := range nodes {
+		if err := merged.Merge(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := stream[len(stream)-1].at
+	for _, window := range []time.Duration{10 * time.Second, time.Minute} {
+		for key := uint64(1); key <= 20; key++ {
+			cnt, total := exactWindow(stream, key, now.Add(-window), now)
+			est, _ := merged.CountWindowT(intToBuf(int32(key)), window, now)
+			// twice eps after a merge
+			if est < cnt*0.9 || est > cnt+0.1*total {
+				t.Errorf("window %v key %d: merged estimate %f, exact %f", window, key, est, cnt)
+			}
+		}
+	}
+	other, _ := streaming.MakeECMSketch(0.05, 0.01, 2, 5*time.Minute, nil)
+	if err := merged.Merge(other); err != streaming.ErrIncompatibleSketches {
+		t.Errorf("merging different seeds should fail, got %v", err)
+	}
+}
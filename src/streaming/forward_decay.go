@@ -0,0 +1,150 @@
+package streaming
+
+import (
+	"math"
+	"time"
+)
+
+// a forward decay function g of the seconds x since the landmark. an item at t_i read at t
+// weighs g(t_i - L) / g(t - L)
+type ForwardDecay struct {
+	log2G func(x float64) float64
+	// g(x - d) = g(x) / g(d), so the landmark can move by d
+	shiftable bool
+}
+
+// g(x) = e^(-decay x), the same as MakeExpCMS with decay whatever the landmark. decay is
+// negative like there, so a count read d seconds later weighs e^(decay d)
+func ExpForwardDecay(decay float64) ForwardDecay {
+	return ForwardDecay{func(x float64) float64 { return -decay * x / math.Ln2 }, true}
+}
+
+// g(x) = x^beta. items at or before the landmark weigh nothing
+func PolyForwardDecay(beta float64) ForwardDecay {
+	return ForwardDecay{func(x float64) float64 {
+		if x <= 0 {
+			return math.Inf(-1)
+		}
+		return beta * math.Log2(x)
+	}, false}
+}
+
+// once log2 g(t - L) is this far above the scale of the cells, they are rebased
+const REBASE_EXPONENT = 256
+
+// count min sketch with forward decay, from "Forward Decay: A Practical Time Decay Model for
+// Streaming Systems" (Cormode, Shkapenyuk, Srivastava, Xu)
+// cells hold weights already multiplied by g(t_i - L), so they need no timestamps and only
+// reads divide by g(t - L). to keep g in range they hold w g(t_i - L) 2^-scale, and get rebased
+// when g outgrows that. exponential decay moves the landmark to the update, which keeps
+// g(t - L) small enough to compute exactly and costs the cells one rounding. other functions
+// can't move the landmark, so scale rises instead and the cells are multiplied by a power of 2,
+// which is exact
+type ForwardDecayCMS struct {
+	weights    []float64 // row after row, then the stream total
+	numBuckets uint32
+	k          uint32
+	seed       int64
+	landmark   int64 // unix nanos
+	g          ForwardDecay
+	scale      int
+	cells      []int
+}
+
+// the landmark should be no later than the first update
+func MakeForwardDecayCMS(eps float64, p_error float64, seed int64, landmark time.Time, g ForwardDecay) *ForwardDecayCMS {
+	size, numHashes := estimate(eps, p_error)
+	return &ForwardDecayCMS{
+		weights:    make([]float64, int(size)*int(numHashes)+1),
+		numBuckets: size,
+		k:          numHashes,
+		seed:       seed,
+		landmark:   landmark.UnixNano(),
+		g:          g,
+		cells:      make([]int, numHashes),
+	}
+}
+
+func (f *ForwardDecayCMS) Seed() int64 {
+	return f.seed
+}
+
+// log2 g of t
+func (f *ForwardDecayCMS) log2G(t time.Time) float64 {
+	return f.g.log2G(float64(t.UnixNano()-f.landmark) / 1e9)
+}
+
+func (f *ForwardDecayCMS) rebase(t time.Time, exp float64) {
+	if f.g.shiftable {
+		factor := math.Exp2(float64(f.scale) - exp)
+		for i := range f.weights {
+			f.weights[i] *= factor
+		}
+		f.landmark, f.scale = t.UnixNano(), 0
+		return
+	}
+	shift := int(exp) - f.scale
+	for i, w := range f.weights {
+		f.weights[i] = math.Ldexp(w, -shift)
+	}
+	f.scale += shift
+}
+
+func (f *ForwardDecayCMS) Update(data []byte, weight float64) {
+	f.UpdateT(data, weight, time.Now())
+}
+
+func (f *ForwardDecayCMS) UpdateT(data []byte, weight float64, updateTime time.Time) {
+	exp := f.log2G(updateTime)
+	if math.IsInf(exp, -1) {
+		return
+	}
+	if exp-float64(f.scale) > REBASE_EXPONENT {
+		f.rebase(updateTime, exp)
+		exp = f.log2G(updateTime)
+	}
+	scaled := weight * math.Exp2(exp-float64(f.scale))
+	fillCells(f.cells, f.seed, f.numBuckets, data)
+	for _, cell := range f.cells {
+		f.weights[cell] += scaled
+	}
+	f.weights[len(f.weights)-1] += scaled
+}
+
+func (f *ForwardDecayCMS) Count(data []byte) (float64, error) {
+	return f.CountT(data, time.Now())
+}
+
+// reads are meant for times after every update; earlier ones weigh the later items above 1
+func (f *ForwardDecayCMS) CountT(data []byte, readTime time.Time) (float64, error) {
+	if f.k == 0 {
+		return 0, ErrElementNotFound
+	}
+	fillCells(f.cells, f.seed, f.numBuckets, data)
+	min := MAX_FLOAT64
+	for _, cell := range f.cells {
+		if f.weights[cell] < min {
+			min = f.weights[cell]
+		}
+	}
+	return f.decayed(min, readTime), nil
+}
+
+func (f *ForwardDecayCMS) decayed(w float64, readTime time.Time) float64 {
+	if w == 0 {
+		return 0
+	}
+	return w * math.Exp2(float64(f.scale)-f.log2G(readTime))
+}
+
+// the decayed weight of the whole stream
+func (f *ForwardDecayCMS) TotalWeightT(readTime time.Time) float64 {
+	return f.decayed(f.weights[len(f.weights)-1], readTime)
+}
+
+func (f *ForwardDecayCMS) Reset() {
+	for i := range f.weights {
+		f.weights[i] = 0
+	}
+	f.scale = 0
+}
@@ -0,0 +1,86 @@
+package streaming_test
+
+import (
+	"math"
+	"streaming"
+	"testing"
+	"time"
+)
+
+func TestForwardDecayMatchesExpCMS(t *testing.T) {
+	now := time.Now()
+	items, actual := zipfStream(10, 20000)
+	f := streaming.MakeForwardDecayCMS(0.01, 0.01, 2, now, streaming.ExpForwardDecay(-0.1))
+	cms := streaming.MakeExpCMS(0.01, 0.01, 2, -0.1)
+	for i, x := range items {
+		at := now.Add(time.Duration(i) * time.Millisecond)
+		f.UpdateT(intToBuf(int32(x)), 1, at)
+		cms.UpdateT(intToBuf(int32(x)), 1, at)
+	}
+	end := now.Add(30 * time.Second)
+	for x := range actual {
+		want, _ := cms.CountT(intToBuf(int32(x)), end)
+		got, _ := f.CountT(intToBuf(int32(x)), end)
+		if math.Abs(got-want) > 1e-9*math.Max(1, want) {
+			t.Fatalf("key %d: forward decay %g, exponential decay %g", x, got, want)
+		}
+	}
+}
+
+// an hour at rate 1/s scales weights by e^3600, far past float64, so this only works if the
+// landmark moves
+func TestForwardDecayLongGaps(t *testing.T) {
+	now := time.Now()
+	k1, k2 := []byte("hello"), []byte("world")
+	f := streaming.MakeForwardDecayCMS(0.01, 0.01, 2, now, streaming.ExpForwardDecay(-1))
+	f.UpdateT(k1, 1, now)
+	for i := 1; i <= 24; i++ {
+		at := now.Add(time.Duration(i) * time.Hour)
+		f.UpdateT(k2, 1, at)
+		if cnt, _ := f.CountT(k2, at.Add(time.Second)); math.Abs(cnt-math.Exp(-1)) > 1e-12 {
+			t.Fatalf("after %d hours count should be %g, was %g", i, math.Exp(-1), cnt)
+		}
+		if cnt, _ := f.CountT(k1, at); cnt != 0 {
+			t.Fatalf("after %d hours the first key should have decayed to 0, was %g", i, cnt)
+		}
+	}
+	if total := f.TotalWeightT(now.Add(24 * time.Hour)); math.Abs(total-1) > 1e-12 {
+		t.Errorf("total weight should be 1, was %g", total)
+	}
+}
+
+func TestForwardDecayPolynomial(t *testing.T) {
+	landmark := time.Now()
+	k1 := []byte("hello")
+	f := streaming.MakeForwardDecayCMS(0.01, 0.01, 2, landmark, streaming.PolyForwardDecay(2))
+	want := 0.0
+	end := landmark.Add(1000 * time.Second)
+	for i := 1; i <= 100; i++ {
+		f.UpdateT(k1, 1, landmark.Add(time.Duration(i)*10*time.Second))
+		want += math.Pow(float64(i*10)/1000, 2)
+	}
+	if cnt, _ := f.CountT(k1, end); math.Abs(cnt-want) > 1e-9 {
+		t.Errorf("polynomially decayed count should be %g, was %g", want, cnt)
+	}
+	// items at the landmark weigh nothing
+	f.UpdateT([]byte("world"), 1, landmark)
+	if cnt, _ := f.CountT([]byte("world"), end); cnt != 0 {
+		t.Errorf("item at the landmark should weigh 0, was %g", cnt)
+	}
+}
+
+// x^40 passes 2^256 after 85 seconds, so the cells get rebased on the way
+func TestForwardDecayPolynomialRebase(t *testing.T) {
+	landmark := time.Now()
+	k1 := []byte("hello")
+	f := streaming.MakeForwardDecayCMS(0.01, 0.01, 2, landmark, streaming.PolyForwardDecay(40))
+	want := 0.0
+	end := landmark.Add(100000 * time.Second)
+	for i := 1; i <= 1000; i++ {
+		f.UpdateT(k1, 1, landmark.Add(time.Duration(i)*100*time.Second))
+		want += math.Pow(float64(i)/1000, 40)
+	}
+	if cnt, _ := f.CountT(k1, end); math.Abs(cnt-want) > 1e-12*want {
+		t.Errorf("polynomially decayed count should be %g, was %g", want, cnt)
+	}
+}
@@ -0,0 +1,161 @@
+package streaming
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"time"
+)
+
+type HeavyHitter struct {
+	Key   string
+	Count float64
+}
+
+// a key in the heap, ordered by score. for exponentially decayed sketches every estimate
+// decays by the same factor, so keys refreshed at different times compare by the log of their
+// estimate moved back to time 0, ln(count) - decay t, which no amount of decay overflows
+type candidate struct {
+	HeavyHitter
+	score float64
+}
+
+type hitterHeap struct {
+	entries   []candidate
+	positions map[string]int
+}
+
+func (h hitterHeap) Len() int           { return len(h.entries) }
+func (h hitterHeap) Less(i, j int) bool { return h.entries[i].score < h.entries[j].score }
+func (h hitterHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.positions[h.entries[i].Key] = i
+	h.positions[h.entries[j].Key] = j
+}
+func (h *hitterHeap) Push(x interface{}) {
+	c := x.(candidate)
+	h.positions[c.Key] = len(h.entries)
+	h.entries = append(h.entries, c)
+}
+func (h *hitterHeap) Pop() interface{} {
+	c := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	delete(h.positions, c.Key)
+	return c
+}
+
+// the k keys with the highest estimates in a CountMin, kept in a min-heap that every update
+// refreshes with the key's new estimate. a key above phi N is sure to be among them while
+// k >= 1 / phi, since at most 1 / phi keys can be
+type HeavyHitters struct {
+	sketch     *CountMin
+	candidates hitterHeap
+	k          int
+}
+
+// sketch can be plain or exponentially decayed, and should be empty
+func MakeHeavyHitters(sketch *CountMin, k int) *HeavyHitters {
+	return &HeavyHitters{sketch, hitterHeap{nil, make(map[string]int)}, k}
+}
+
+func (hh *HeavyHitters) Sketch() *CountMin {
+	return hh.sketch
+}
+
+func (hh *HeavyHitters) Update(data []byte, weight float64) {
+	hh.UpdateT(data, weight, time.Now())
+}
+
+func (hh *HeavyHitters) UpdateT(data []byte, weight float64, updateTime time.Time) {
+	hh.sketch.UpdateT(data, weight, updateTime)
+	est, err := hh.sketch.CountT(data, updateTime)
+	if err != nil || hh.k <= 0 {
+		return
+	}
+	hh.offer(data, est, updateTime)
+}
+
+func (hh *HeavyHitters) score(est float64, t time.Time) float64 {
+	if hh.sketch.decayMode != DECAY_EXP {
+		return est
+	}
+	if est <= 0 {
+		return math.Inf(-1)
+	}
+	return math.Log(est) - hh.sketch.decay*float64(t.UnixNano())/1e9
+}
+
+func (hh *HeavyHitters) offer(data []byte, est float64, t time.Time) {
+	score := hh.score(est, t)
+	if i, ok := hh.candidates.positions[string(data)]; ok {
+		hh.candidates.entries[i].Count = est
+		hh.candidates.entries[i].score = score
+		heap.Fix(&hh.candidates, i)
+		return
+	}
+	c := candidate{HeavyHitter{string(data), est}, score}
+	if hh.candidates.Len() < hh.k {
+		heap.Push(&hh.candidates, c)
+		return
+	}
+	if min := hh.candidates.entries[0]; score > min.score {
+		delete(hh.candidates.positions, min.Key)
+		hh.candidates.entries[0] = c
+		hh.candidates.positions[c.Key] = 0
+		heap.Fix(&hh.candidates, 0)
+	}
+}
+
+func (hh *HeavyHitters) Count(data []byte) (float64, error) {
+	return hh.sketch.Count(data)
+}
+
+func (hh *HeavyHitters) Reset() {
+	hh.sketch.Reset()
+	hh.candidates = hitterHeap{nil, make(map[string]int)}
+}
+
+// N, the weight of the stream so far, decayed like the counts
+func (hh *HeavyHitters) TotalWeight() float64 {
+	return hh.TotalWeightT(time.Now())
+}
+
+func (hh *HeavyHitters) TotalWeightT(readTime time.Time) float64 {
+	return hh.sketch.TotalWeightT(readTime)
+}
+
+// the tracked keys, highest first, with their estimates at readTime
+func (hh *HeavyHitters) TopKT(readTime time.Time) []HeavyHitter {
+	result := make([]HeavyHitter, 0, hh.candidates.Len())
+	for _, c := range hh.candidates.entries {
+		est, _ := hh.sketch.CountT([]byte(c.Key), readTime)
+		result = append(result, HeavyHitter{c.Key, est})
+	}
+	sort.Sort(byCount(result))
+	return result
+}
+
+func (hh *HeavyHitters) TopK() []HeavyHitter {
+	return hh.TopKT(time.Now())
+}
+
+// the tracked keys estimated above phi N at readTime, highest first
+func (hh *HeavyHitters) HeavyHittersT(phi float64, readTime time.Time) []HeavyHitter {
+	threshold := phi * hh.TotalWeightT(readTime)
+	top := hh.TopKT(readTime)
+	n := 0
+	for n < len(top) && top[n].Count > threshold {
+		n++
+	}
+	return top[:n]
+}
+
+func (hh *HeavyHitters) HeavyHitters(phi float64) []HeavyHitter {
+	return hh.HeavyHittersT(phi, time.Now())
+}
+
+type byCount []HeavyHitter
+
+func (h byCount) Len() int           { return len(h) }
+func (h byCount) Less(i, j int) bool { return h[i].Count > h[j].Count }
+func (h byCount) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
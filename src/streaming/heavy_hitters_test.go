@@ -0,0 +1,70 @@
+package streaming_test
+
+import (
+	"streaming"
+	"testing"
+	"time"
+)
+
+func TestHeavyHittersZipf(t *testing.T) {
+	phi := 0.01
+	items, actual := zipfStream(21, 200000)
+	hh := streaming.MakeHeavyHitters(streaming.MakeCMS(0.001, 0.01, 0), 200)
+	for _, x := range items {
+		hh.Update(intToBuf(int32(x)), 1)
+	}
+	n := float64(len(items))
+	if hh.TotalWeight() != n {
+		t.Errorf("total weight should be %f, was %f", n, hh.TotalWeight())
+	}
+	found := make(map[string]bool)
+	for _, h := range hh.HeavyHitters(phi) {
+		found[h.Key] = true
+	}
+	for x, cnt := range actual {
+		key := string(intToBuf(int32(x)))
+		if cnt > phi*n && !found[key] {
+			t.Errorf("key %d with count %.0f > %.0f is missing", x, cnt, phi*n)
+		}
+		// the sketch overestimates by at most eps N, with eps = 0.001
+		if found[key] && cnt <= (phi-0.001)*n {
+			t.Errorf("key %d with count %.0f shouldn't be a heavy hitter", x, cnt)
+		}
+	}
+	top := hh.TopK()
+	if len(top) != 200 {
+		t.Fatalf("should track 200 keys, tracks %d", len(top))
+	}
+	for i := 1; i < len(top); i++ {
+		if top[i].Count > top[i-1].Count {
+			t.Fatalf("top k isn't sorted at %d", i)
+		}
+	}
+}
+
+func TestHeavyHittersDecayed(t *testing.T) {
+	now := time.Now()
+	old, recent := []byte("old"), []byte("recent")
+	hh := streaming.MakeHeavyHitters(streaming.MakeExpCMS(0.01, 0.01, 0, -0.1), 1)
+	hh.UpdateT(old, 100, now)
+	// decays to 100 e^-5, about 0.67, by the time recent arrives
+	later := now.Add(50 * time.Second)
+	hh.UpdateT(recent, 10, later)
+	top := hh.TopKT(later)
+	if len(top) != 1 || top[0].Key != "recent" {
+		t.Fatalf("recent key should have replaced the decayed one, top is %v", top)
+	}
+	if hh := hh.HeavyHittersT(0.5, later); len(hh) != 1 {
+		t.Errorf("recent key holds most of the decayed weight, heavy hitters are %v", hh)
+	}
+}
+
+func TestHeavyHittersKeepsHeavierKey(t *testing.T) {
+	now := time.Now()
+	hh := streaming.MakeHeavyHitters(streaming.MakeExpCMS(0.01, 0.01, 0, -0.1), 1)
+	hh.UpdateT([]byte("heavy"), 100, now)
+	hh.UpdateT([]byte("light"), 10, now.Add(time.Second))
+	if top := hh.TopKT(now.Add(time.Second)); top[0].Key != "heavy" {
+		t.Errorf("heavy key should stay, top is %v", top)
+	}
+}
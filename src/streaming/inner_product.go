@@ -0,0 +1,44 @@
+package streaming
+
+import (
+	"time"
+)
+
+// estimates sum_x f_a(x) f_b(x), the size of the join of the two streams on their keys. every
+// row's dot product overestimates it, by at most eps N_a N_b with probability 1/2 since a
+// row has 2 / eps buckets, so the smallest over the rows is within that with probability
+// 1 - p_error. returns the estimate and that bound. the sketches need the same dimensions,
+// seed and decay, and decayed ones are read now
+func InnerProduct(a *CountMin, b *CountMin) (float64, float64, error) {
+	return InnerProductT(a, b, time.Now())
+}
+
+func InnerProductT(a *CountMin, b *CountMin, readTime time.Time) (float64, float64, error) {
+	if !a.compatible(b) {
+		return 0, 0, ErrIncompatibleSketches
+	}
+	if a.k == 0 {
+		return 0, 0, ErrElementNotFound
+	}
+	min := MAX_FLOAT64
+	w := int(a.numBuckets)
+	for row := 0; row < int(a.k); row++ {
+		dot := 0.0
+		for cell := row * w; cell < (row+1)*w; cell++ {
+			if a.weights[cell] != 0 && b.weights[cell] != 0 {
+				dot += a.readCell(cell, readTime) * b.readCell(cell, readTime)
+			}
+		}
+		if dot < min {
+			min = dot
+		}
+	}
+	eps := 2 / float64(a.numBuckets)
+	return min, eps * a.readCell(a.totalCell(), readTime) * b.readCell(b.totalCell(), readTime), nil
+}
+
+// estimates F2 = sum_x f(x)^2, the size of the stream's join with itself, and its error bound
+func SelfJoin(cms *CountMin) (float64, float64) {
+	est, bound, _ := InnerProduct(cms, cms)
+	return est, bound
+}
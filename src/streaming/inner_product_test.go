@@ -0,0 +1,59 @@
+package streaming_test
+
+import (
+	"streaming"
+	"testing"
+)
+
+func TestInnerProduct(t *testing.T) {
+	itemsA, actualA := zipfStream(1, 100000)
+	itemsB, actualB := zipfStream(2, 50000)
+	a := streaming.MakeCMS(0.001, 0.01, 4)
+	b := streaming.MakeCMS(0.001, 0.01, 4)
+	for _, x := range itemsA {
+		a.Update(intToBuf(int32(x)), 1)
+	}
+	// shift b's keys so only part of the heavy keys are shared
+	for _, x := range itemsB {
+		b.Update(intToBuf(int32(x+3)), 1)
+	}
+	exact := 0.0
+	for x, cnt := range actualB {
+		exact += cnt * actualA[x+3]
+	}
+	est, bound, err := streaming.InnerProduct(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("join size %.0f, estimate %.0f, bound %.0f", exact, est, bound)
+	if est < exact || est-exact > bound {
+		t.Errorf("join estimate %.0f outside [%.0f, %.0f]", est, exact, exact+bound)
+	}
+}
+
+func TestSelfJoin(t *testing.T) {
+	items, actual := zipfStream(3, 100000)
+	cms := streaming.MakeCMS(0.001, 0.01, 4)
+	for _, x := range items {
+		cms.Update(intToBuf(int32(x)), 1)
+	}
+	exact := 0.0
+	for _, cnt := range actual {
+		exact += cnt * cnt
+	}
+	est, bound := streaming.SelfJoin(cms)
+	t.Logf("F2 %.0f, estimate %.0f, bound %.0f", exact, est, bound)
+	if est < exact || est-exact > bound {
+		t.Errorf("F2 estimate %.0f outside [%.0f, %.0f]", est, exact, exact+bound)
+	}
+}
+
+func TestInnerProductIncompatible(t *testing.T) {
+	a := streaming.MakeCMS(0.001, 0.01, 1)
+	if _, _, err := streaming.InnerProduct(a, streaming.MakeCMS(0.001, 0.01, 2)); err != streaming.ErrIncompatibleSketches {
+		t.Errorf("different seeds should fail, got %v", err)
+	}
+	if _, _, err := streaming.InnerProduct(a, streaming.MakeExpCMS(0.001, 0.01, 1, -0.1)); err != streaming.ErrIncompatibleSketches {
+		t.Errorf("different decay should fail, got %v", err)
+	}
+}
@@ -3,12 +3,26 @@ package streaming
 import (
 	"container/list"
 	"errors"
+	"math"
+	"time"
 )
 
+var ErrInvalidSlices = errors.New("cms: slice range outside the window")
+
+// returns the current time, replaceable in tests
+type Clock func() time.Time
+
+// count min sketches over consecutive slices of a stream, newest at the front. slices end
+// after limit updates, or in time mode when the clock passes their end
 type WindowedCMS struct {
 	sketches *list.List
 	Counter  int64
 	limit    int64
+	// time mode only: slices cover sliceNanos each, aligned to the unix epoch, and the front
+	// one is number current
+	sliceNanos int64
+	current    int64
+	clock      Clock
 }
 
 // makes window/sliceLength sketches. The sketches use the eps, p_error, and seed params
@@ -17,27 +31,105 @@ func MakeWindowedCMS(eps float64, p_error float64, seed int64, window int64, sli
 	if sliceLength <= 0 || window <= 0 || window < sliceLength {
 		return nil, errors.New("invalid input to MakeWindowedCMS")
 	}
+	return &WindowedCMS{sketches: makeSlices(eps, p_error, seed, window/sliceLength), limit: sliceLength}, nil
+}
+
+// makes window/sliceLength sketches, each covering sliceLength of time as told by clock, or
+// time.Now if it is nil
+func MakeTimedWindowedCMS(eps float64, p_error float64, seed int64, window time.Duration, sliceLength time.Duration,
+	clock Clock) (*WindowedCMS, error) {
+	if sliceLength <= 0 || window <= 0 || window < sliceLength {
+		return nil, errors.New("invalid input to MakeTimedWindowedCMS")
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	sketches := makeSlices(eps, p_error, seed, int64(window/sliceLength))
+	return &WindowedCMS{sketches: sketches, sliceNanos: int64(sliceLength), clock: clock}, nil
+}
+
+func makeSlices(eps float64, p_error float64, seed int64, numSketches int64) *list.List {
 	size, numHashes := estimate(eps, p_error)
-	numSketches := window / sliceLength
 	sketches := list.New()
 	for i := int64(0); i < numSketches; i++ {
-		sketches.PushFront(MakeCMSDirect(size, numHashes, seed, Plain_update, Plain_read))
+		sketches.PushFront(makeCMS(size, numHashes, seed, DECAY_NONE, 0, UPDATE_ALL, READ_MIN))
 	}
-	return &WindowedCMS{sketches, 0, sliceLength}, nil
+	return sketches
+}
+
+func (w *WindowedCMS) timed() bool {
+	return w.sliceNanos > 0
+}
+
+// the number of the slice t falls in
+func (w *WindowedCMS) sliceOf(t time.Time) int64 {
+	n := t.UnixNano()
+	if n < 0 {
+		return (n+1)/w.sliceNanos - 1
+	}
+	return n / w.sliceNanos
+}
+
+// moves the oldest slice to the front, emptied
+func (w *WindowedCMS) rotate() {
+	v := w.sketches.Remove(w.sketches.Back())
+	w.sketches.PushFront(v)
+	w.sketches.Front().Value.(*CountMin).Reset()
+}
+
+// rotates until the front slice is number slice. a gap of the whole window or more empties
+// every slice
+func (w *WindowedCMS) advance(slice int64) {
+	gap := slice - w.current
+	if gap <= 0 {
+		return
+	}
+	if gap > int64(w.sketches.Len()) {
+		gap = int64(w.sketches.Len())
+	}
+	for i := int64(0); i < gap; i++ {
+		w.rotate()
+	}
+	w.current = slice
 }
 
 func (w *WindowedCMS) Update(data []byte, weight float64) {
+	if w.timed() {
+		w.UpdateT(data, weight, w.clock())
+		return
+	}
 	if w.Counter == w.limit {
-		v := w.sketches.Remove(w.sketches.Front())
-		w.sketches.PushBack(v)
-		w.sketches.Front().Value.(*CountMin).Reset()
+		w.rotate()
 		w.Counter = 0
 	}
 	w.sketches.Front().Value.(*CountMin).Update(data, weight)
 	w.Counter += 1
 }
 
+// adds the update to the slice updateTime falls in. updates older than the window are dropped.
+// without time mode this is Update
+func (w *WindowedCMS) UpdateT(data []byte, weight float64, updateTime time.Time) {
+	if !w.timed() {
+		w.Update(data, weight)
+		return
+	}
+	slice := w.sliceOf(updateTime)
+	w.advance(slice)
+	age := w.current - slice
+	if age >= int64(w.sketches.Len()) {
+		return
+	}
+	e := w.sketches.Front()
+	for i := int64(0); i < age; i++ {
+		e = e.Next()
+	}
+	e.Value.(*CountMin).Update(data, weight)
+}
+
 func (w *WindowedCMS) Count(data []byte) (float64, error) {
+	if w.timed() {
+		return w.CountT(data, w.clock())
+	}
 	cnt := 0.0
 	for e := w.sketches.Front(); e != nil; e = e.Next() {
 		sketch := e.Value.(*CountMin)
@@ -47,8 +139,139 @@ func (w *WindowedCMS) Count(data []byte) (float64, error) {
 	return cnt, nil
 }
 
+// counts the slices of the window ending in the slice readTime falls in, without rotating.
+// without time mode this is Count
+func (w *WindowedCMS) CountT(data []byte, readTime time.Time) (float64, error) {
+	if !w.timed() {
+		return w.Count(data)
+	}
+	slice := w.sliceOf(readTime)
+	n := int64(w.sketches.Len())
+	cnt := 0.0
+	i := w.current
+	for e := w.sketches.Front(); e != nil; e = e.Next() {
+		if i <= slice && i > slice-n {
+			c, _ := e.Value.(*CountMin).Count(data)
+			cnt += c
+		}
+		i--
+	}
+	return cnt, nil
+}
+
 func (w *WindowedCMS) Reset() {
 	for e := w.sketches.Front(); e != nil; e = e.Next() {
 		e.Value.(*CountMin).Reset()
 	}
 }
+
+// the slices newest first, each at its age in slices at readTime. slices that are empty at
+// readTime in time mode are nil
+func (w *WindowedCMS) slicesAt(readTime time.Time) []*CountMin {
+	slices := make([]*CountMin, w.sketches.Len())
+	if !w.timed() {
+		i := 0
+		for e := w.sketches.Front(); e != nil; e = e.Next() {
+			slices[i] = e.Value.(*CountMin)
+			i++
+		}
+		return slices
+	}
+	age := w.sliceOf(readTime) - w.current
+	for e := w.sketches.Front(); e != nil && age < int64(len(slices)); e = e.Next() {
+		if age >= 0 {
+			slices[age] = e.Value.(*CountMin)
+		}
+		age++
+	}
+	return slices
+}
+
+func (w *WindowedCMS) now() time.Time {
+	if w.timed() {
+		return w.clock()
+	}
+	return time.Time{}
+}
+
+// the estimate of a slice and its error bound, eps N_slice with eps = 2 / numBuckets. each
+// slice is within its bound with probability 1 - p_error
+func sliceCount(sketch *CountMin, data []byte) (float64, float64) {
+	if sketch == nil {
+		return 0, 0
+	}
+	c, _ := sketch.Count(data)
+	return c, sketch.Epsilon() * sketch.TotalWeight()
+}
+
+// counts the newest nSlices slices, in time mode the ones ending now. returns the estimate
+// and the sum of the slices' bounds, which holds with probability 1 - nSlices p_error
+func (w *WindowedCMS) CountLast(data []byte, nSlices int) (float64, float64, error) {
+	slices := w.slicesAt(w.now())
+	if nSlices < 1 || nSlices > len(slices) {
+		return 0, 0, ErrInvalidSlices
+	}
+	cnt, bound := 0.0, 0.0
+	for _, sketch := range slices[:nSlices] {
+		c, b := sliceCount(sketch, data)
+		cnt += c
+		bound += b
+	}
+	return cnt, bound, nil
+}
+
+// the count of every slice, oldest first, and its error bound
+func (w *WindowedCMS) Series(data []byte) ([]float64, []float64) {
+	slices := w.slicesAt(w.now())
+	counts, bounds := make([]float64, len(slices)), make([]float64, len(slices))
+	for i, sketch := range slices {
+		j := len(slices) - 1 - i
+		counts[j], bounds[j] = sliceCount(sketch, data)
+	}
+	return counts, bounds
+}
+
+// merges the slices from age from to age to, inclusive and counted from the newest at 0, in
+// time mode ending now, into one sketch for the range
+func (w *WindowedCMS) MergedSnapshot(from int, to int) (*CountMin, error) {
+	slices := w.slicesAt(w.now())
+	if from < 0 || from > to || to >= len(slices) {
+		return nil, ErrInvalidSlices
+	}
+	first := w.sketches.Front().Value.(*CountMin)
+	merged := makeCMS(first.numBuckets, first.k, first.seed, DECAY_NONE, 0, UPDATE_ALL, READ_MIN)
+	for _, sketch := range slices[from : to+1] {
+		if sketch != nil {
+			if err := merged.Merge(sketch); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return merged, nil
+}
+
+// the eps of the slices, since their errors add up to at most eps times their total weight
+func (w *WindowedCMS) Epsilon() float64 {
+	return w.sketches.Front().Value.(*CountMin).Epsilon()
+}
+
+// the probability that any slice in the window is off by more than its bound
+func (w *WindowedCMS) Delta() float64 {
+	return math.Min(1, float64(w.sketches.Len())*w.sketches.Front().Value.(*CountMin).Delta())
+}
+
+// the weight of the slices in the window, in time mode the one ending now
+func (w *WindowedCMS) TotalWeight() float64 {
+	total := 0.0
+	for _, sketch := range w.slicesAt(w.now()) {
+		if sketch != nil {
+			total += sketch.TotalWeight()
+		}
+	}
+	return total
+}
+
+func (w *WindowedCMS) CountWithBounds(data []byte) (float64, float64, float64) {
+	est, bound, _ := w.CountLast(data, w.sketches.Len())
+	return est, math.Max(0, est-bound), est
+}
@@ -0,0 +1,223 @@
+package streaming_test
+
+import (
+	"math"
+	"streaming"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func makeTimedWindow(t *testing.T) (*streaming.WindowedCMS, *fakeClock) {
+	clock := &fakeClock{time.Unix(1000000, 0)}
+	w, err := streaming.MakeTimedWindowedCMS(0.01, 0.01, 5, time.Minute, 10*time.Second, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, clock
+}
+
+func TestTimedWindowExpires(t *testing.T) {
+	w, clock := makeTimedWindow(t)
+	k1 := []byte("hello")
+	for i := 0; i < 6; i++ {
+		w.Update(k1, 1.0)
+		clock.Advance(10 * time.Second)
+	}
+	// the first update's slice just left the window
+	if cnt, _ := w.Count(k1); math.Abs(cnt-5.0) > eps {
+		t.Errorf("cnt should be 5.0, but was %f", cnt)
+	}
+	clock.Advance(30 * time.Second)
+	if cnt, _ := w.Count(k1); math.Abs(cnt-2.0) > eps {
+		t.Errorf("cnt should be 2.0, but was %f", cnt)
+	}
+}
+
+func TestTimedWindowBurstsDontRotate(t *testing.T) {
+	w, clock := makeTimedWindow(t)
+	k1 := []byte("hello")
+	for i := 0; i < 10000; i++ {
+		w.Update(k1, 1.0)
+	}
+	clock.Advance(50 * time.Second)
+	if cnt, _ := w.Count(k1); math.Abs(cnt-10000.0) > eps {
+		t.Errorf("a burst within the window should all count, cnt was %f", cnt)
+	}
+}
+
+func TestTimedWindowIdleGap(t *testing.T) {
+	w, clock := makeTimedWindow(t)
+	k1, k2 := []byte("hello"), []byte("world")
+	w.Update(k1, 1.0)
+	// idle for several windows, then a new update has to find every old slice emptied
+	clock.Advance(5 * time.Minute)
+	w.Update(k2, 1.0)
+	if cnt, _ := w.Count(k1); cnt != 0 {
+		t.Errorf("cnt should be 0 after an idle gap, but was %f", cnt)
+	}
+	// a gap shorter than the window keeps the slices still inside it
+	clock.Advance(35 * time.Second)
+	w.Update(k1, 1.0)
+	if cnt, _ := w.Count(k2); math.Abs(cnt-1.0) > eps {
+		t.Errorf("cnt should be 1.0, but was %f", cnt)
+	}
+	if cnt, _ := w.CountT(k2, clock.Now().Add(30*time.Second)); cnt != 0 {
+		t.Errorf("cnt should be 0 a window after the update, but was %f", cnt)
+	}
+}
+
+func TestTimedWindowLateUpdates(t *testing.T) {
+	w, clock := makeTimedWindow(t)
+	k1 := []byte("hello")
+	clock.Advance(time.Minute)
+	now := clock.Now()
+	w.UpdateT(k1, 1.0, now)
+	w.UpdateT(k1, 1.0, now.Add(-30*time.Second))
+	// older than the window
+	w.UpdateT(k1, 1.0, now.Add(-2*time.Minute))
+	if cnt, _ := w.Count(k1); math.Abs(cnt-2.0) > eps {
+		t.Errorf("cnt should be 2.0, but was %f", cnt)
+	}
+	if cnt, _ := w.CountT(k1, now.Add(35*time.Second)); math.Abs(cnt-1.0) > eps {
+		t.Errorf("the late update should have left the window, cnt was %f", cnt)
+	}
+}
+
+func TestTimedWindowEncodingRoundTrip(t *testing.T) {
+	w, clock := makeTimedWindow(t)
+	k1 := []byte("hello")
+	w.Update(k1, 1.0)
+	clock.Advance(30 * time.Second)
+	w.Update(k1, 2.0)
+	data, err := w.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _ := streaming.MakeTimedWindowedCMS(0.1, 0.1, 0, time.Second, time.Second, clock.Now)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(35 * time.Second)
+	if cnt, _ := decoded.Count(k1); math.Abs(cnt-2.0) > eps {
+		t.Errorf("cnt should be 2.0, but was %f", cnt)
+	}
+}
+
+func TestWindowedCountLast(t *testing.T) {
+	w, clock := makeTimedWindow(t)
+	k1 := []byte("hello")
+	// 1, 2, ..., 6 in consecutive slices
+	for i := 1; i <= 6; i++ {
+		w.Update(k1, float64(i))
+		if i < 6 {
+			clock.Advance(10 * time.Second)
+		}
+	}
+	for n, want := range map[int]float64{1: 6, 2: 11, 6: 21} {
+		cnt, bound, err := w.CountLast(k1, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cnt < want || cnt-want > bound {
+			t.Errorf("last %d slices: estimate %f, want %f within %f", n, cnt, want, bound)
+		}
+	}
+	if _, _, err := w.CountLast(k1, 7); err != streaming.ErrInvalidSlices {
+		t.Errorf("more slices than the window should fail, got %v", err)
+	}
+	clock.Advance(20 * time.Second)
+	if cnt, _, _ := w.CountLast(k1, 3); math.Abs(cnt-6) > eps {
+		t.Errorf("last 3 slices two slices later should count 6, was %f", cnt)
+	}
+}
+
+func TestWindowedSeries(t *testing.T) {
+	w, _ := streaming.MakeWindowedCMS(0.01, 0.01, 5, 4, 2)
+	k1 := []byte("hello")
+	for i := 0; i < 7; i++ {
+		w.Update(k1, float64(i))
+	}
+	// slices of two updates: 0+1, 2+3, 4+5, and 6 in the newest
+	counts, bounds := w.Series(k1)
+	want := []float64{9, 6}
+	if len(counts) != len(want) || len(bounds) != len(want) {
+		t.Fatalf("series should have %d slices, has %d", len(want), len(counts))
+	}
+	for i := range want {
+		if counts[i] < want[i] || counts[i]-want[i] > bounds[i] {
+			t.Errorf("slice %d: estimate %f, want %f within %f", i, counts[i], want[i], bounds[i])
+		}
+	}
+}
+
+func TestWindowedMergedSnapshot(t *testing.T) {
+	w, clock := makeTimedWindow(t)
+	items, _ := zipfStream(4, 6000)
+	exact := make(map[int32]float64)
+	for i, x := range items {
+		w.Update(intToBuf(int32(x)), 1)
+		// the middle slices 1 to 3 of the final window
+		if i >= 2000 && i < 5000 {
+			exact[int32(x)]++
+		}
+		if i%1000 == 999 {
+			clock.Advance(10 * time.Second)
+		}
+	}
+	clock.Advance(-10 * time.Second)
+	merged, err := w.MergedSnapshot(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3000 updates in the range, with eps = 0.01
+	bound := 0.01 * 3000
+	for x, cnt := range exact {
+		if est, _ := merged.Count(intToBuf(x)); est < cnt || est-cnt > bound {
+			t.Fatalf("key %d: merged %f, exact %f", x, est, cnt)
+		}
+	}
+	if _, err := w.MergedSnapshot(2, 6); err != streaming.ErrInvalidSlices {
+		t.Errorf("range past the window should fail, got %v", err)
+	}
+}
+
+func TestWindowedCountWithBounds(t *testing.T) {
+	w, clock := makeTimedWindow(t)
+	items, actual := zipfStream(13, 30000)
+	for i, x := range items {
+		w.Update(intToBuf(int32(x)), 1)
+		if i%5000 == 4999 && i < len(items)-1 {
+			clock.Advance(10 * time.Second)
+		}
+	}
+	var bounded streaming.BoundedCMSketch = w
+	if bounded.TotalWeight() != 30000 {
+		t.Errorf("total weight should be 30000, was %f", bounded.TotalWeight())
+	}
+	if bounded.Delta() != 6.0/128 {
+		t.Errorf("delta should be 6 slices of 1/128, was %f", bounded.Delta())
+	}
+	for x, cnt := range actual {
+		est, lower, upper := bounded.CountWithBounds(intToBuf(int32(x)))
+		if cnt < lower || cnt > upper || est != upper {
+			t.Fatalf("key %d: count %f outside [%f, %f]", x, cnt, lower, upper)
+		}
+	}
+	// the oldest slice leaves the window
+	clock.Advance(10 * time.Second)
+	if bounded.TotalWeight() != 25000 {
+		t.Errorf("total weight should be 25000, was %f", bounded.TotalWeight())
+	}
+}